@@ -0,0 +1,100 @@
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// SecretStoreReadyCondition reports whether the store's provider was created.
+const SecretStoreReadyCondition = "Ready"
+
+// SecretStoreSpec describes a provider instance.
+type SecretStoreSpec struct {
+	// Type is the provider implementation (e.g. "aws-secretsmanager", "vault-kv").
+	// +kubebuilder:validation:MinLength=1
+	Type string `json:"type"`
+
+	// Config holds the provider settings, using the same keys as the
+	// entries of the controller's provider configuration file.
+	// +optional
+	// +kubebuilder:pruning:PreserveUnknownFields
+	Config *runtime.RawExtension `json:"config,omitempty"`
+
+	// SecretRefs sets provider settings from Secret keys so that credentials
+	// do not have to be stored in the spec. Map keys are setting names
+	// (e.g. "token") and take precedence over Config.
+	// +optional
+	SecretRefs map[string]SecretKeyReference `json:"secretRefs,omitempty"`
+}
+
+// SecretKeyReference selects a key of a Secret.
+type SecretKeyReference struct {
+	// Name of the Secret.
+	Name string `json:"name"`
+	// Key within the Secret.
+	Key string `json:"key"`
+	// Namespace of the Secret. Required for ClusterSecretStores; a
+	// SecretStore always reads Secrets from its own namespace.
+	// +optional
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// SecretStoreStatus reports whether the provider could be created.
+type SecretStoreStatus struct {
+	// Conditions holds the Ready condition of the store.
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// SecretStore is a provider instance usable by pods in its own namespace.
+type SecretStore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SecretStoreSpec   `json:"spec"`
+	Status SecretStoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SecretStoreList contains a list of SecretStore.
+type SecretStoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SecretStore `json:"items"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Type",type=string,JSONPath=`.spec.type`
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterSecretStore is a provider instance usable by pods in every namespace.
+type ClusterSecretStore struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SecretStoreSpec   `json:"spec"`
+	Status SecretStoreStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterSecretStoreList contains a list of ClusterSecretStore.
+type ClusterSecretStoreList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterSecretStore `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SecretStore{}, &SecretStoreList{}, &ClusterSecretStore{}, &ClusterSecretStoreList{})
+}
@@ -10,17 +10,21 @@ package main
 import (
 	"context"
 	"flag"
+	"fmt"
 	"os"
+	"time"
 
 	"go.uber.org/zap/zapcore"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
+	jasmv1alpha1 "github.com/codnod/jasm/api/v1alpha1"
 	"github.com/codnod/jasm/internal/controller"
 	"github.com/codnod/jasm/internal/provider"
 )
@@ -32,18 +36,75 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(jasmv1alpha1.AddToScheme(scheme))
 }
 
 func main() {
 	var metricsAddr string
 	var probeAddr string
 	var enableLeaderElection bool
+	vaultConfig := provider.VaultConfigFromEnv()
+	var gcpConfig provider.GCPSecretManagerConfig
+	var fileProviderRoot string
+	var sopsConfig provider.SOPSConfig
+	var providerConfigFile string
+	var enableSecretStores bool
+	var awsConfig provider.AWSConfig
+	var awsNamespaceRolesFile string
+	var ownerReferences bool
+	var enableOrphanGC bool
+	var orphanGCInterval time.Duration
+	var orphanGCGracePeriod time.Duration
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.StringVar(&vaultConfig.KubernetesRole, "vault-kubernetes-role", "",
+		"Vault role to log in as using the Kubernetes auth method. "+
+			"When empty, the static token from VAULT_TOKEN is used.")
+	flag.StringVar(&vaultConfig.KubernetesMountPath, "vault-kubernetes-mount-path", "kubernetes",
+		"Mount path of the Vault Kubernetes auth backend.")
+	flag.StringVar(&vaultConfig.ServiceAccountTokenPath, "vault-kubernetes-token-path",
+		"/var/run/secrets/kubernetes.io/serviceaccount/token",
+		"Path of the ServiceAccount JWT presented to Vault when logging in.")
+	flag.BoolVar(&gcpConfig.Enabled, "enable-gcp-secretmanager", false,
+		"Enable the GCP Secret Manager provider. "+
+			"Access tokens are obtained from the metadata server (GKE Workload Identity).")
+	flag.StringVar(&fileProviderRoot, "file-provider-root", "",
+		"Directory served by the file provider (e.g. a mounted volume). "+
+			"The file provider is disabled when empty.")
+	flag.StringVar(&sopsConfig.AgeKeyFile, "sops-age-key-file", os.Getenv("SOPS_AGE_KEY_FILE"),
+		"File holding the age private key(s) used to decrypt SOPS documents. "+
+			"The sops provider is disabled when empty.")
+	flag.StringVar(&sopsConfig.Root, "sops-root", "",
+		"Directory SOPS-encrypted files are read from. "+
+			"When empty, the sops provider only reads ConfigMaps.")
+
+	flag.StringVar(&awsNamespaceRolesFile, "aws-namespace-roles", "",
+		"YAML or JSON file mapping namespaces to the IAM roles assumed when fetching AWS secrets for them.")
+	flag.BoolVar(&awsConfig.NamespaceRoleAnnotation, "aws-namespace-role-annotation", false,
+		"Also read a namespace's IAM role from its "+provider.AWSRoleAnnotation+" annotation.")
+	flag.BoolVar(&awsConfig.RequireNamespaceRole, "aws-require-namespace-role", false,
+		"Reject AWS requests from namespaces without a role instead of using the controller's identity.")
+	flag.StringVar(&providerConfigFile, "provider-config", "",
+		"YAML or JSON file declaring named provider instances. "+
+			"When set, only the declared providers are registered; the provider flags above act as defaults.")
+
+	flag.BoolVar(&enableSecretStores, "enable-secret-stores", true,
+		"Watch SecretStore and ClusterSecretStore objects and register the providers they describe. "+
+			"Requires the jasm.codnod.io CRDs to be installed.")
+
+	flag.BoolVar(&ownerReferences, "owner-references", false,
+		"Make the top-level controller of each pod (e.g. its Deployment) an owner of the secrets written for it, "+
+			"so that Kubernetes deletes them along with their last owner.")
+	flag.BoolVar(&enableOrphanGC, "enable-orphan-gc", false,
+		"Periodically delete managed secrets and ConfigMaps that no pod annotation references anymore.")
+	flag.DurationVar(&orphanGCInterval, "orphan-gc-interval", 10*time.Minute,
+		"Time between orphan collection passes.")
+	flag.DurationVar(&orphanGCGracePeriod, "orphan-gc-grace-period", time.Hour,
+		"How long a managed object must stay unreferenced before it is deleted.")
 
 	opts := zap.Options{
 		Development: true,
@@ -56,6 +117,12 @@ func main() {
 
 	setupLog.Info("Starting Caronte controller", "version", "0.1.0")
 
+	if enableOrphanGC && (orphanGCInterval <= 0 || orphanGCGracePeriod <= 0) {
+		setupLog.Error(fmt.Errorf("--orphan-gc-interval and --orphan-gc-grace-period must be positive, got %s and %s",
+			orphanGCInterval, orphanGCGracePeriod), "invalid orphan collector settings")
+		os.Exit(1)
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
@@ -70,24 +137,91 @@ func main() {
 		os.Exit(1)
 	}
 
+	if awsNamespaceRolesFile != "" {
+		awsConfig.NamespaceRoles, err = provider.LoadAWSNamespaceRoles(awsNamespaceRolesFile)
+		if err != nil {
+			setupLog.Error(err, "unable to load AWS namespace roles")
+			os.Exit(1)
+		}
+	}
+
 	ctx := context.Background()
-	providerRegistry, err := provider.DefaultProviderRegistry(ctx)
+	registryOptions := provider.RegistryOptions{
+		ConfigFile: providerConfigFile,
+		AWS:        awsConfig,
+		Vault:      vaultConfig,
+		Azure:      provider.AzureKeyVaultConfigFromEnv(),
+		GCP:        gcpConfig,
+		// Use the cached client: the controller already watches Secrets.
+		KubeClient:  mgr.GetClient(),
+		FileRoot:    fileProviderRoot,
+		SOPS:        sopsConfig,
+		OnePassword: provider.OnePasswordConfigFromEnv(),
+		Bitwarden:   provider.BitwardenConfigFromEnv(),
+	}
+	providerRegistry, err := provider.DefaultProviderRegistry(ctx, registryOptions)
 	if err != nil {
 		setupLog.Error(err, "unable to initialize provider registry")
 		os.Exit(1)
 	}
 	setupLog.Info("Initialized provider registry", "providers", providerRegistry.List())
 
+	// Store reconcilers hand over the pods using a store once it is
+	// registered, so that they need not wait for their next update.
+	var podEvents chan event.GenericEvent
+	if enableSecretStores {
+		podEvents = make(chan event.GenericEvent, 1024)
+	}
+
 	if err = (&controller.PodSecretReconciler{
 		Client:           mgr.GetClient(),
 		Scheme:           mgr.GetScheme(),
 		Recorder:         mgr.GetEventRecorderFor("jasm"),
 		ProviderRegistry: providerRegistry,
+		OwnerReferences:  ownerReferences,
+		// Read pod owners directly so that no informers are started for them.
+		APIReader: mgr.GetAPIReader(),
+		PodEvents: podEvents,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "PodSecret")
 		os.Exit(1)
 	}
 
+	if enableOrphanGC {
+		if err = mgr.Add(&controller.OrphanCollector{
+			Client:      mgr.GetClient(),
+			Interval:    orphanGCInterval,
+			GracePeriod: orphanGCGracePeriod,
+		}); err != nil {
+			setupLog.Error(err, "unable to set up orphan collector")
+			os.Exit(1)
+		}
+	}
+
+	if enableSecretStores {
+		if err = (&controller.ClusterSecretStoreReconciler{
+			Client:           mgr.GetClient(),
+			Scheme:           mgr.GetScheme(),
+			ProviderRegistry: providerRegistry,
+			Defaults:         registryOptions,
+			PodEvents:        podEvents,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "ClusterSecretStore")
+			os.Exit(1)
+		}
+		if err = (&controller.SecretStoreReconciler{
+			Client:           mgr.GetClient(),
+			Scheme:           mgr.GetScheme(),
+			ProviderRegistry: providerRegistry,
+			KubeClient:       mgr.GetClient(),
+			AWS:              registryOptions.AWS,
+			PodEvents:        podEvents,
+		}).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "SecretStore")
+			os.Exit(1)
+		}
+	}
+
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
 		os.Exit(1)
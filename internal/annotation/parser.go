@@ -4,22 +4,64 @@ package annotation
 
 import (
 	"fmt"
+	"strings"
 
 	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/codnod/jasm/internal/mapping"
 )
 
 const (
 	// AnnotationKey is the annotation key for secret sync configuration
 	AnnotationKey = "jasm.codnod.io/secret-sync"
+	// ReservedDomain is the domain of the labels and annotations JASM sets
+	// itself; sync entries may not set keys in it or its subdomains.
+	ReservedDomain = "jasm.codnod.io"
+
+	// KindSecret is the default target kind: data is written to a Secret.
+	KindSecret = "Secret"
+	// KindConfigMap writes data to a ConfigMap, for non-sensitive values.
+	KindConfigMap = "ConfigMap"
 )
 
-// PodAnnotation represents the parsed annotation structure.
+// reservedLabels are labels outside ReservedDomain that JASM sets itself.
+var reservedLabels = map[string]bool{
+	"app.kubernetes.io/managed-by": true,
+}
+
+// PodAnnotation represents a single sync entry of the annotation.
 type PodAnnotation struct {
-	Provider   string            `yaml:"provider"`
-	Path       string            `yaml:"path"`
-	SecretName string            `yaml:"secretName"`
-	Keys       map[string]string `yaml:"keys"`
+	Provider     string             `yaml:"provider"`
+	Path         string             `yaml:"path"`
+	SecretName   string             `yaml:"secretName"`
+	Keys         map[string]string  `yaml:"keys"`
+	Region       string             `yaml:"region"`
+	Version      string             `yaml:"version"`
+	VersionStage string             `yaml:"versionStage"`
+	Format       string             `yaml:"format"`
+	TextKey      string             `yaml:"textKey"`
+	Flatten      bool               `yaml:"flatten"`
+	Sources      []SourceAnnotation `yaml:"sources"`
+	Templates    map[string]string  `yaml:"templates"`
+	Type         string             `yaml:"type"`
+	Labels       map[string]string  `yaml:"labels"`
+	Annotations  map[string]string  `yaml:"annotations"`
+	Kind         string             `yaml:"kind"`
+}
+
+// SourceAnnotation is one entry of the sources list of a sync entry. Fields
+// left empty are taken from the sync entry.
+type SourceAnnotation struct {
+	Provider     string `yaml:"provider"`
+	Path         string `yaml:"path"`
+	Region       string `yaml:"region"`
+	Version      string `yaml:"version"`
+	VersionStage string `yaml:"versionStage"`
+	Format       string `yaml:"format"`
+	TextKey      string `yaml:"textKey"`
 }
 
 // SecretSyncRequest represents a complete secret synchronization request.
@@ -31,6 +73,52 @@ type SecretSyncRequest struct {
 	PodName    string
 	PodUID     types.UID
 	KeyMapping map[string]string
+	// Region overrides the provider's default region (AWS providers only).
+	Region string
+	// Version and VersionStage select the secret version to read
+	// (AWS Secrets Manager only). Empty means the current version.
+	Version      string
+	VersionStage string
+	// Format is "json" (the default) or "text"; TextKey names the key a
+	// plain-text value is stored under (AWS Secrets Manager only).
+	Format  string
+	TextKey string
+	// Flatten expands nested JSON values into underscore-joined keys
+	// (e.g. db_primary_password) before keys are mapped.
+	Flatten bool
+	// Sources lists the provider paths the secret is assembled from, in
+	// increasing order of precedence: a key fetched from a later source
+	// replaces the same key from an earlier one. An entry with a single
+	// path has a single source; SecretPath is empty for a sources list.
+	Sources []SecretSource
+	// Templates maps Kubernetes secret keys to text/template templates
+	// rendered against the fetched data. Rendered keys replace mapped keys
+	// of the same name.
+	Templates map[string]string
+	// Type is the type of the Kubernetes secret; its required keys are
+	// checked before the secret is written. Empty means the type of the
+	// source Secret for a copy of a single Kubernetes Secret, and Opaque
+	// otherwise.
+	Type corev1.SecretType
+	// Labels and Annotations are stamped on the Kubernetes secret in
+	// addition to the ones JASM sets.
+	Labels      map[string]string
+	Annotations map[string]string
+	// Kind is the kind of object written, KindSecret or KindConfigMap;
+	// SecretName names it either way.
+	Kind string
+}
+
+// SecretSource is a provider path a secret is fetched from, along with the
+// options to fetch it with.
+type SecretSource struct {
+	Provider     string
+	Path         string
+	Region       string
+	Version      string
+	VersionStage string
+	Format       string
+	TextKey      string
 }
 
 // ParseAnnotation parses the secret sync annotation from a pod.
@@ -45,26 +133,232 @@ func ParseAnnotation(annotationValue, namespace, podName string, podUID types.UI
 		return nil, fmt.Errorf("failed to parse annotation YAML: %w", err)
 	}
 
+	return newSyncRequest(podAnnotation, namespace, podName, podUID)
+}
+
+// ParseAnnotations parses a secret sync annotation holding either a single
+// sync entry (a YAML object) or a list of entries, one per Kubernetes secret.
+// Entries must target distinct objects.
+func ParseAnnotations(annotationValue, namespace, podName string, podUID types.UID) ([]*SecretSyncRequest, error) {
+	if annotationValue == "" {
+		return nil, fmt.Errorf("annotation value is empty")
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(annotationValue), &root); err != nil {
+		return nil, fmt.Errorf("failed to parse annotation YAML: %w", err)
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.SequenceNode {
+		syncRequest, err := ParseAnnotation(annotationValue, namespace, podName, podUID)
+		if err != nil {
+			return nil, err
+		}
+		return []*SecretSyncRequest{syncRequest}, nil
+	}
+
+	var podAnnotations []PodAnnotation
+	if err := root.Content[0].Decode(&podAnnotations); err != nil {
+		return nil, fmt.Errorf("failed to parse annotation YAML: %w", err)
+	}
+	if len(podAnnotations) == 0 {
+		return nil, fmt.Errorf("annotation has no sync entries")
+	}
+
+	syncRequests := make([]*SecretSyncRequest, 0, len(podAnnotations))
+	targets := make(map[string]int, len(podAnnotations))
+	for i, podAnnotation := range podAnnotations {
+		syncRequest, err := newSyncRequest(podAnnotation, namespace, podName, podUID)
+		if err != nil {
+			return nil, fmt.Errorf("entry %d: %w", i, err)
+		}
+		target := syncRequest.Kind + "/" + syncRequest.SecretName
+		if previous, exists := targets[target]; exists {
+			return nil, fmt.Errorf("entry %d: secretName %s is already used by entry %d", i, syncRequest.SecretName, previous)
+		}
+		targets[target] = i
+		syncRequests = append(syncRequests, syncRequest)
+	}
+	return syncRequests, nil
+}
+
+// newSyncRequest validates a single sync entry and builds its request.
+func newSyncRequest(podAnnotation PodAnnotation, namespace, podName string, podUID types.UID) (*SecretSyncRequest, error) {
 	// Validate required fields
-	if podAnnotation.Provider == "" {
+	if podAnnotation.Path != "" && len(podAnnotation.Sources) > 0 {
+		return nil, fmt.Errorf("path and sources are mutually exclusive")
+	}
+	if podAnnotation.Provider == "" && len(podAnnotation.Sources) == 0 {
 		return nil, fmt.Errorf("provider field is required")
 	}
-	if podAnnotation.Path == "" {
+	if podAnnotation.Path == "" && len(podAnnotation.Sources) == 0 {
 		return nil, fmt.Errorf("path field is required")
 	}
 	if podAnnotation.SecretName == "" {
 		return nil, fmt.Errorf("secretName field is required")
 	}
 
+	sources := []SecretSource{{
+		Provider:     podAnnotation.Provider,
+		Path:         podAnnotation.Path,
+		Region:       podAnnotation.Region,
+		Version:      podAnnotation.Version,
+		VersionStage: podAnnotation.VersionStage,
+		Format:       podAnnotation.Format,
+		TextKey:      podAnnotation.TextKey,
+	}}
+	if len(podAnnotation.Sources) > 0 {
+		sources = make([]SecretSource, 0, len(podAnnotation.Sources))
+		for _, sourceAnnotation := range podAnnotation.Sources {
+			sources = append(sources, newSource(sourceAnnotation, podAnnotation))
+		}
+	}
+	for i, source := range sources {
+		if err := validateSource(source); err != nil {
+			if len(podAnnotation.Sources) > 0 {
+				return nil, fmt.Errorf("sources[%d]: %w", i, err)
+			}
+			return nil, err
+		}
+	}
+
+	for key, text := range podAnnotation.Templates {
+		if _, err := mapping.ParseTemplate(key, text); err != nil {
+			return nil, fmt.Errorf("templates.%s: %w", key, err)
+		}
+	}
+
+	// The type is left empty when unset, so that copies of Kubernetes
+	// Secrets can keep the type of their source.
+	var secretType corev1.SecretType
+	if podAnnotation.Type != "" {
+		var err error
+		secretType, err = mapping.ParseSecretType(podAnnotation.Type)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := validateMetadata(podAnnotation.Labels, podAnnotation.Annotations); err != nil {
+		return nil, err
+	}
+
+	kind := KindSecret
+	switch podAnnotation.Kind {
+	case "", KindSecret:
+	case KindConfigMap:
+		kind = KindConfigMap
+		if secretType != "" && secretType != corev1.SecretTypeOpaque {
+			return nil, fmt.Errorf("type cannot be set for kind %s", KindConfigMap)
+		}
+	default:
+		return nil, fmt.Errorf("kind must be %s or %s, got %q", KindSecret, KindConfigMap, podAnnotation.Kind)
+	}
+
 	// TODO: Validate secretName is a valid Kubernetes name (DNS-1123 label)
 
 	return &SecretSyncRequest{
-		Provider:   podAnnotation.Provider,
-		SecretPath: podAnnotation.Path,
-		SecretName: podAnnotation.SecretName,
-		Namespace:  namespace,
-		PodName:    podName,
-		PodUID:     podUID,
-		KeyMapping: podAnnotation.Keys,
+		Provider:     podAnnotation.Provider,
+		SecretPath:   podAnnotation.Path,
+		SecretName:   podAnnotation.SecretName,
+		Namespace:    namespace,
+		PodName:      podName,
+		PodUID:       podUID,
+		KeyMapping:   podAnnotation.Keys,
+		Region:       podAnnotation.Region,
+		Version:      podAnnotation.Version,
+		VersionStage: podAnnotation.VersionStage,
+		Format:       podAnnotation.Format,
+		TextKey:      podAnnotation.TextKey,
+		Flatten:      podAnnotation.Flatten,
+		Sources:      sources,
+		Templates:    podAnnotation.Templates,
+		Type:         secretType,
+		Labels:       podAnnotation.Labels,
+		Annotations:  podAnnotation.Annotations,
+		Kind:         kind,
 	}, nil
 }
+
+// validateMetadata checks the labels and annotations of a sync entry, which
+// must be valid Kubernetes metadata and must not use reserved keys.
+func validateMetadata(labels, annotations map[string]string) error {
+	for key, value := range labels {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("labels.%s: invalid key: %s", key, strings.Join(errs, "; "))
+		}
+		if errs := validation.IsValidLabelValue(value); len(errs) > 0 {
+			return fmt.Errorf("labels.%s: invalid value: %s", key, strings.Join(errs, "; "))
+		}
+		if isReservedKey(key) || reservedLabels[key] {
+			return fmt.Errorf("labels.%s: key is reserved for JASM", key)
+		}
+	}
+	for key := range annotations {
+		if errs := validation.IsQualifiedName(key); len(errs) > 0 {
+			return fmt.Errorf("annotations.%s: invalid key: %s", key, strings.Join(errs, "; "))
+		}
+		if isReservedKey(key) {
+			return fmt.Errorf("annotations.%s: key is reserved for JASM", key)
+		}
+	}
+	return nil
+}
+
+// isReservedKey reports whether a label or annotation key is prefixed with
+// ReservedDomain or one of its subdomains.
+func isReservedKey(key string) bool {
+	prefix, _, found := strings.Cut(key, "/")
+	if !found {
+		return false
+	}
+	prefix = strings.ToLower(prefix)
+	return prefix == ReservedDomain || strings.HasSuffix(prefix, "."+ReservedDomain)
+}
+
+// newSource builds a source of a sources list. The provider, region,
+// staging label and format default to those of the sync entry; a version ID
+// names a single secret and is never inherited.
+func newSource(sourceAnnotation SourceAnnotation, podAnnotation PodAnnotation) SecretSource {
+	source := SecretSource{
+		Provider:     valueOr(sourceAnnotation.Provider, podAnnotation.Provider),
+		Path:         sourceAnnotation.Path,
+		Region:       valueOr(sourceAnnotation.Region, podAnnotation.Region),
+		Version:      sourceAnnotation.Version,
+		VersionStage: valueOr(sourceAnnotation.VersionStage, podAnnotation.VersionStage),
+		Format:       sourceAnnotation.Format,
+		TextKey:      sourceAnnotation.TextKey,
+	}
+	if source.Format == "" {
+		source.Format = podAnnotation.Format
+		source.TextKey = valueOr(source.TextKey, podAnnotation.TextKey)
+	}
+	return source
+}
+
+// validateSource checks the settings of a single source.
+func validateSource(source SecretSource) error {
+	if source.Provider == "" {
+		return fmt.Errorf("provider field is required")
+	}
+	if source.Path == "" {
+		return fmt.Errorf("path field is required")
+	}
+
+	switch source.Format {
+	case "", "json", "text":
+	default:
+		return fmt.Errorf("format must be json or text, got %q", source.Format)
+	}
+	if source.TextKey != "" && source.Format != "text" {
+		return fmt.Errorf("textKey requires format: text")
+	}
+	return nil
+}
+
+// valueOr returns value, or fallback when value is empty.
+func valueOr(value, fallback string) string {
+	if value != "" {
+		return value
+	}
+	return fallback
+}
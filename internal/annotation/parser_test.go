@@ -1,8 +1,11 @@
 package annotation
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/types"
 )
 
@@ -103,6 +106,23 @@ func TestParseAnnotationValidation(t *testing.T) {
 			wantErr:    true,
 			errMsg:     "secretName field is required",
 		},
+		{
+			name:       "Unknown format",
+			annotation: "provider: aws-secretsmanager\npath: /test\nsecretName: test\nformat: xml",
+			wantErr:    true,
+			errMsg:     "format must be json or text",
+		},
+		{
+			name:       "textKey without text format",
+			annotation: "provider: aws-secretsmanager\npath: /test\nsecretName: test\ntextKey: token",
+			wantErr:    true,
+			errMsg:     "textKey requires format: text",
+		},
+		{
+			name:       "Text format",
+			annotation: "provider: aws-secretsmanager\npath: /test\nsecretName: test\nformat: text\ntextKey: token",
+			wantErr:    false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -114,3 +134,410 @@ func TestParseAnnotationValidation(t *testing.T) {
 		})
 	}
 }
+
+func TestParseAnnotationWithRegion(t *testing.T) {
+	annotationValue := `
+provider: aws-secretsmanager
+path: /prod/myapp/database
+secretName: db-credentials
+region: us-east-1
+`
+
+	result, err := ParseAnnotation(annotationValue, "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Region != "us-east-1" {
+		t.Errorf("Expected region 'us-east-1', got %s", result.Region)
+	}
+}
+
+func TestParseAnnotationWithVersion(t *testing.T) {
+	annotationValue := `
+provider: aws-secretsmanager
+path: /prod/myapp/database
+secretName: db-credentials
+version: 3f1e2d4c-0000-4000-8000-000000000000
+versionStage: AWSPENDING
+`
+
+	result, err := ParseAnnotation(annotationValue, "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if result.Version != "3f1e2d4c-0000-4000-8000-000000000000" {
+		t.Errorf("Expected version '3f1e2d4c-0000-4000-8000-000000000000', got %s", result.Version)
+	}
+	if result.VersionStage != "AWSPENDING" {
+		t.Errorf("Expected versionStage 'AWSPENDING', got %s", result.VersionStage)
+	}
+}
+
+func TestParseAnnotationWithFlatten(t *testing.T) {
+	annotationValue := `
+provider: aws-secretsmanager
+path: /prod/myapp/database
+secretName: db-credentials
+flatten: true
+keys:
+  DB_PASSWORD: db.primary.password
+`
+
+	result, err := ParseAnnotation(annotationValue, "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if !result.Flatten {
+		t.Error("Expected flatten to be set")
+	}
+	if result.KeyMapping["DB_PASSWORD"] != "db.primary.password" {
+		t.Errorf("Expected key mapping 'db.primary.password', got %s", result.KeyMapping["DB_PASSWORD"])
+	}
+}
+
+func TestParseAnnotations(t *testing.T) {
+	annotationValue := `
+- provider: aws-secretsmanager
+  path: /prod/myapp/database
+  secretName: db-credentials
+- provider: aws-ssm
+  path: /prod/myapp/api-key
+  secretName: api-key
+  keys:
+    API_KEY: api-key
+`
+
+	results, err := ParseAnnotations(annotationValue, "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(results) != 2 {
+		t.Fatalf("Expected 2 entries, got %d", len(results))
+	}
+	if results[0].SecretName != "db-credentials" || results[0].Provider != "aws-secretsmanager" {
+		t.Errorf("Unexpected first entry: %+v", results[0])
+	}
+	if results[1].SecretName != "api-key" || results[1].KeyMapping["API_KEY"] != "api-key" {
+		t.Errorf("Unexpected second entry: %+v", results[1])
+	}
+	for _, result := range results {
+		if result.Namespace != "default" || result.PodName != "test-pod" {
+			t.Errorf("Expected namespace and pod to be set, got %+v", result)
+		}
+	}
+}
+
+func TestParseAnnotationsSingleObject(t *testing.T) {
+	annotationValue := `
+provider: aws-secretsmanager
+path: /prod/myapp/database
+secretName: db-credentials
+`
+
+	results, err := ParseAnnotations(annotationValue, "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	if len(results) != 1 || results[0].SecretName != "db-credentials" {
+		t.Errorf("Expected a single db-credentials entry, got %+v", results)
+	}
+}
+
+func TestParseAnnotationsValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		errMsg     string
+	}{
+		{
+			name:       "Empty list",
+			annotation: "[]",
+			errMsg:     "annotation has no sync entries",
+		},
+		{
+			name:       "Invalid entry",
+			annotation: "- provider: aws-secretsmanager\n  path: /a\n  secretName: a\n- provider: aws-secretsmanager\n  secretName: b",
+			errMsg:     "entry 1: path field is required",
+		},
+		{
+			name:       "Duplicate secretName",
+			annotation: "- provider: aws-secretsmanager\n  path: /a\n  secretName: a\n- provider: aws-ssm\n  path: /b\n  secretName: a",
+			errMsg:     "entry 1: secretName a is already used by entry 0",
+		},
+		{
+			name:       "Invalid single object",
+			annotation: "provider: aws-secretsmanager\nsecretName: a",
+			errMsg:     "path field is required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAnnotations(tt.annotation, "default", "test-pod", types.UID("uid-123"))
+			if err == nil {
+				t.Fatalf("Expected error containing %q", tt.errMsg)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestParseAnnotationWithSources(t *testing.T) {
+	annotationValue := `
+provider: aws-secretsmanager
+region: eu-west-1
+secretName: app-secrets
+sources:
+  - path: /prod/common
+  - path: /prod/myapp
+    version: 3f1e2d4c-0000-4000-8000-000000000000
+  - provider: vault-kv
+    path: secret/data/myapp
+    region: ""
+`
+
+	result, err := ParseAnnotation(annotationValue, "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []SecretSource{
+		{Provider: "aws-secretsmanager", Path: "/prod/common", Region: "eu-west-1"},
+		{Provider: "aws-secretsmanager", Path: "/prod/myapp", Region: "eu-west-1", Version: "3f1e2d4c-0000-4000-8000-000000000000"},
+		{Provider: "vault-kv", Path: "secret/data/myapp", Region: "eu-west-1"},
+	}
+	if !reflect.DeepEqual(result.Sources, want) {
+		t.Errorf("Expected sources %+v, got %+v", want, result.Sources)
+	}
+	if result.SecretPath != "" {
+		t.Errorf("Expected empty SecretPath, got %s", result.SecretPath)
+	}
+}
+
+func TestParseAnnotationSingleSource(t *testing.T) {
+	annotationValue := `
+provider: aws-secretsmanager
+path: /prod/myapp/token
+secretName: api-token
+format: text
+textKey: token
+`
+
+	result, err := ParseAnnotation(annotationValue, "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	want := []SecretSource{{Provider: "aws-secretsmanager", Path: "/prod/myapp/token", Format: "text", TextKey: "token"}}
+	if !reflect.DeepEqual(result.Sources, want) {
+		t.Errorf("Expected sources %+v, got %+v", want, result.Sources)
+	}
+}
+
+func TestParseAnnotationSourcesValidation(t *testing.T) {
+	tests := []struct {
+		name       string
+		annotation string
+		errMsg     string
+	}{
+		{
+			name:       "Path and sources",
+			annotation: "provider: aws-ssm\npath: /a\nsecretName: a\nsources:\n  - path: /b",
+			errMsg:     "path and sources are mutually exclusive",
+		},
+		{
+			name:       "Source without provider",
+			annotation: "secretName: a\nsources:\n  - provider: aws-ssm\n    path: /a\n  - path: /b",
+			errMsg:     "sources[1]: provider field is required",
+		},
+		{
+			name:       "Source without path",
+			annotation: "provider: aws-ssm\nsecretName: a\nsources:\n  - region: eu-west-1",
+			errMsg:     "sources[0]: path field is required",
+		},
+		{
+			name:       "Source with invalid format",
+			annotation: "provider: aws-ssm\nsecretName: a\nsources:\n  - path: /a\n    format: xml",
+			errMsg:     "sources[0]: format must be json or text",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseAnnotation(tt.annotation, "default", "test-pod", types.UID("uid-123"))
+			if err == nil {
+				t.Fatalf("Expected error containing %q", tt.errMsg)
+			}
+			if !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestParseAnnotationWithTemplates(t *testing.T) {
+	annotationValue := `
+provider: aws-secretsmanager
+path: /prod/myapp/database
+secretName: db-credentials
+templates:
+  DATABASE_URL: "postgres://{{ .username }}:{{ .password }}@{{ .host }}/db"
+`
+
+	result, err := ParseAnnotation(annotationValue, "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Templates["DATABASE_URL"] != "postgres://{{ .username }}:{{ .password }}@{{ .host }}/db" {
+		t.Errorf("Unexpected templates: %v", result.Templates)
+	}
+
+	_, err = ParseAnnotation(`
+provider: aws-secretsmanager
+path: /prod/myapp/database
+secretName: db-credentials
+templates:
+  DATABASE_URL: "{{ .username | nosuchfunc }}"
+`, "default", "test-pod", types.UID("uid-123"))
+	if err == nil || !strings.Contains(err.Error(), "templates.DATABASE_URL") {
+		t.Errorf("Expected template error, got %v", err)
+	}
+}
+
+func TestParseAnnotationWithType(t *testing.T) {
+	annotationValue := `
+provider: aws-secretsmanager
+path: /prod/ingress/tls
+secretName: ingress-tls
+type: tls
+`
+
+	result, err := ParseAnnotation(annotationValue, "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Type != corev1.SecretTypeTLS {
+		t.Errorf("Expected type %s, got %s", corev1.SecretTypeTLS, result.Type)
+	}
+
+	result, err = ParseAnnotation("provider: aws-ssm\npath: /a\nsecretName: a", "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Type != "" {
+		t.Errorf("Expected no type, got %s", result.Type)
+	}
+
+	_, err = ParseAnnotation("provider: aws-ssm\npath: /a\nsecretName: a\ntype: certificate", "default", "test-pod", types.UID("uid-123"))
+	if err == nil || !strings.Contains(err.Error(), "unsupported secret type") {
+		t.Errorf("Expected unsupported type error, got %v", err)
+	}
+}
+
+func TestParseAnnotationWithMetadata(t *testing.T) {
+	annotationValue := `
+provider: aws-secretsmanager
+path: /prod/myapp/database
+secretName: db-credentials
+labels:
+  team: payments
+  backup.example.com/enabled: "true"
+annotations:
+  reloader.stakater.com/match: "true"
+`
+
+	result, err := ParseAnnotation(annotationValue, "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Labels["team"] != "payments" || result.Labels["backup.example.com/enabled"] != "true" {
+		t.Errorf("Unexpected labels: %v", result.Labels)
+	}
+	if result.Annotations["reloader.stakater.com/match"] != "true" {
+		t.Errorf("Unexpected annotations: %v", result.Annotations)
+	}
+}
+
+func TestParseAnnotationMetadataValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		metadata string
+		errMsg   string
+	}{
+		{
+			name:     "Reserved label",
+			metadata: "labels:\n  jasm.codnod.io/source-path: x",
+			errMsg:   "labels.jasm.codnod.io/source-path: key is reserved",
+		},
+		{
+			name:     "Reserved subdomain annotation",
+			metadata: "annotations:\n  sync.jasm.codnod.io/owner: x",
+			errMsg:   "annotations.sync.jasm.codnod.io/owner: key is reserved",
+		},
+		{
+			name:     "Managed-by label",
+			metadata: "labels:\n  app.kubernetes.io/managed-by: helm",
+			errMsg:   "labels.app.kubernetes.io/managed-by: key is reserved",
+		},
+		{
+			name:     "Invalid label value",
+			metadata: "labels:\n  team: not a valid value",
+			errMsg:   "labels.team: invalid value",
+		},
+		{
+			name:     "Invalid annotation key",
+			metadata: "annotations:\n  bad key: x",
+			errMsg:   "annotations.bad key: invalid key",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			annotationValue := "provider: aws-ssm\npath: /a\nsecretName: a\n" + tt.metadata
+			_, err := ParseAnnotation(annotationValue, "default", "test-pod", types.UID("uid-123"))
+			if err == nil || !strings.Contains(err.Error(), tt.errMsg) {
+				t.Errorf("Expected error containing %q, got %v", tt.errMsg, err)
+			}
+		})
+	}
+}
+
+func TestParseAnnotationWithKind(t *testing.T) {
+	result, err := ParseAnnotation("provider: aws-ssm\npath: /a\nsecretName: a", "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Kind != KindSecret {
+		t.Errorf("Expected kind %s, got %s", KindSecret, result.Kind)
+	}
+
+	result, err = ParseAnnotation("provider: aws-ssm\npath: /a\nsecretName: a\nkind: ConfigMap", "default", "test-pod", types.UID("uid-123"))
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if result.Kind != KindConfigMap {
+		t.Errorf("Expected kind %s, got %s", KindConfigMap, result.Kind)
+	}
+
+	for annotationValue, errMsg := range map[string]string{
+		"provider: aws-ssm\npath: /a\nsecretName: a\nkind: Deployment":           "kind must be Secret or ConfigMap",
+		"provider: aws-ssm\npath: /a\nsecretName: a\nkind: ConfigMap\ntype: tls": "type cannot be set for kind ConfigMap",
+	} {
+		_, err := ParseAnnotation(annotationValue, "default", "test-pod", types.UID("uid-123"))
+		if err == nil || !strings.Contains(err.Error(), errMsg) {
+			t.Errorf("Expected error containing %q, got %v", errMsg, err)
+		}
+	}
+
+	results, err := ParseAnnotations("- provider: aws-ssm\n  path: /a\n  secretName: a\n- provider: aws-ssm\n  path: /a\n  secretName: a\n  kind: ConfigMap", "default", "test-pod", types.UID("uid-123"))
+	if err != nil || len(results) != 2 {
+		t.Errorf("Expected a Secret and a ConfigMap of the same name, got %v, %v", results, err)
+	}
+}
@@ -0,0 +1,186 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/codnod/jasm/internal/annotation"
+)
+
+// OrphanCollector periodically deletes the Secrets and ConfigMaps JASM
+// manages that no pod's sync annotation references anymore.
+//
+// An object found unreferenced is stamped with OrphanedAtAnnotation and only
+// deleted once it has stayed unreferenced for GracePeriod, so that objects
+// survive rollouts replacing the pods that use them. Namespaces holding a pod
+// with an invalid sync annotation are skipped, since the objects it refers to
+// are unknown.
+type OrphanCollector struct {
+	client.Client
+	// Interval is the time between collection passes.
+	Interval time.Duration
+	// GracePeriod is how long an object must stay unreferenced before it
+	// is deleted.
+	GracePeriod time.Duration
+}
+
+// orphanTarget identifies an object written by a sync entry.
+type orphanTarget struct {
+	kind      string
+	namespace string
+	name      string
+}
+
+// Start runs a collection pass every Interval until ctx is done.
+// +kubebuilder:rbac:groups="",resources=secrets,verbs=delete
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=delete
+func (c *OrphanCollector) Start(ctx context.Context) error {
+	if c.Interval <= 0 || c.GracePeriod <= 0 {
+		return fmt.Errorf("orphan collector interval and grace period must be positive, got %s and %s", c.Interval, c.GracePeriod)
+	}
+
+	logger := log.FromContext(ctx).WithName("orphan-collector")
+	ctx = log.IntoContext(ctx, logger)
+
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := c.Collect(ctx, time.Now()); err != nil {
+				logger.Error(err, "Failed to collect orphaned objects")
+			}
+		}
+	}
+}
+
+// NeedLeaderElection makes the collector run on the leader only.
+func (c *OrphanCollector) NeedLeaderElection() bool {
+	return true
+}
+
+// Collect runs a single collection pass at time now.
+func (c *OrphanCollector) Collect(ctx context.Context, now time.Time) error {
+	referenced, skipped, err := c.referencedTargets(ctx)
+	if err != nil {
+		return err
+	}
+
+	var secrets corev1.SecretList
+	if err := c.List(ctx, &secrets, client.MatchingLabels{ManagedByLabel: ManagedByValue}); err != nil {
+		return fmt.Errorf("failed to list managed secrets: %w", err)
+	}
+	var configMaps corev1.ConfigMapList
+	if err := c.List(ctx, &configMaps, client.MatchingLabels{ManagedByLabel: ManagedByValue}); err != nil {
+		return fmt.Errorf("failed to list managed configmaps: %w", err)
+	}
+
+	var errs []error
+	for i := range secrets.Items {
+		errs = append(errs, c.collect(ctx, &secrets.Items[i], annotation.KindSecret, referenced, skipped, now))
+	}
+	for i := range configMaps.Items {
+		errs = append(errs, c.collect(ctx, &configMaps.Items[i], annotation.KindConfigMap, referenced, skipped, now))
+	}
+	return errors.Join(errs...)
+}
+
+// referencedTargets returns the objects referenced by live pods, and the
+// namespaces holding pods whose references are unknown.
+func (c *OrphanCollector) referencedTargets(ctx context.Context) (map[orphanTarget]bool, map[string]bool, error) {
+	var podList corev1.PodList
+	if err := c.List(ctx, &podList); err != nil {
+		return nil, nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	referenced := make(map[orphanTarget]bool)
+	skipped := make(map[string]bool)
+	for _, pod := range podList.Items {
+		annotationValue, found := pod.Annotations[AnnotationKey]
+		if !found || pod.DeletionTimestamp != nil {
+			continue
+		}
+		syncRequests, err := annotation.ParseAnnotations(annotationValue, pod.Namespace, pod.Name, pod.UID)
+		if err != nil {
+			skipped[pod.Namespace] = true
+			continue
+		}
+		for _, syncRequest := range syncRequests {
+			referenced[orphanTarget{kind: syncRequest.Kind, namespace: pod.Namespace, name: syncRequest.SecretName}] = true
+		}
+	}
+	return referenced, skipped, nil
+}
+
+// collect marks, unmarks or deletes a single managed object.
+func (c *OrphanCollector) collect(ctx context.Context, obj client.Object, kind string, referenced map[orphanTarget]bool, skipped map[string]bool, now time.Time) error {
+	log := log.FromContext(ctx).WithValues("kind", kind, "namespace", obj.GetNamespace(), "name", obj.GetName())
+
+	if skipped[obj.GetNamespace()] {
+		return nil
+	}
+
+	orphanedAt, marked := obj.GetAnnotations()[OrphanedAtAnnotation]
+	if referenced[orphanTarget{kind: kind, namespace: obj.GetNamespace(), name: obj.GetName()}] {
+		if !marked {
+			return nil
+		}
+		log.Info("Object is referenced again, unmarking it")
+		return c.setOrphanedAt(ctx, obj, "")
+	}
+
+	if !marked {
+		log.Info("Object is no longer referenced, marking it for deletion", "gracePeriod", c.GracePeriod)
+		return c.setOrphanedAt(ctx, obj, now.UTC().Format(time.RFC3339))
+	}
+
+	since, err := time.Parse(time.RFC3339, orphanedAt)
+	if err != nil {
+		log.Info("Invalid orphaned-at annotation, marking the object again", "value", orphanedAt)
+		return c.setOrphanedAt(ctx, obj, now.UTC().Format(time.RFC3339))
+	}
+	if now.Sub(since) < c.GracePeriod {
+		return nil
+	}
+
+	// Only delete the object as it was listed, in case a pod has just
+	// started referencing it again.
+	uid := obj.GetUID()
+	resourceVersion := obj.GetResourceVersion()
+	log.Info("Deleting orphaned object", "orphanedAt", orphanedAt)
+	err = c.Delete(ctx, obj, client.Preconditions{UID: &uid, ResourceVersion: &resourceVersion})
+	if err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+		return fmt.Errorf("failed to delete %s %s/%s: %w", kind, obj.GetNamespace(), obj.GetName(), err)
+	}
+	return nil
+}
+
+// setOrphanedAt sets OrphanedAtAnnotation on obj, or removes it when value
+// is empty.
+func (c *OrphanCollector) setOrphanedAt(ctx context.Context, obj client.Object, value string) error {
+	patch := client.MergeFromWithOptions(obj.DeepCopyObject().(client.Object), client.MergeFromWithOptimisticLock{})
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if value == "" {
+		delete(annotations, OrphanedAtAnnotation)
+	} else {
+		annotations[OrphanedAtAnnotation] = value
+	}
+	obj.SetAnnotations(annotations)
+
+	if err := c.Patch(ctx, obj, patch); err != nil && !apierrors.IsNotFound(err) && !apierrors.IsConflict(err) {
+		return fmt.Errorf("failed to update %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestOrphanCollector_Collect(t *testing.T) {
+	now := time.Date(2026, 1, 2, 12, 0, 0, 0, time.UTC)
+	managed := map[string]string{ManagedByLabel: ManagedByValue}
+	orphanedAt := func(age time.Duration) map[string]string {
+		return map[string]string{OrphanedAtAnnotation: now.Add(-age).Format(time.RFC3339)}
+	}
+
+	objects := []client.Object{
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:      "app",
+			Namespace: "default",
+			Annotations: map[string]string{AnnotationKey: `
+- provider: test
+  path: /prod/db
+  secretName: in-use
+- provider: test
+  path: /prod/features
+  secretName: features
+  kind: ConfigMap
+- provider: test
+  path: /prod/api
+  secretName: reused
+`},
+		}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Name:        "broken",
+			Namespace:   "team-b",
+			Annotations: map[string]string{AnnotationKey: "provider: test"},
+		}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "in-use", Namespace: "default", Labels: managed}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "reused", Namespace: "default", Labels: managed, Annotations: orphanedAt(2 * time.Hour)}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "new-orphan", Namespace: "default", Labels: managed}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "recent-orphan", Namespace: "default", Labels: managed, Annotations: orphanedAt(10 * time.Minute)}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "old-orphan", Namespace: "default", Labels: managed, Annotations: orphanedAt(2 * time.Hour)}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: "default", Annotations: orphanedAt(2 * time.Hour)}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "features", Namespace: "default", Labels: managed, Annotations: orphanedAt(2 * time.Hour)}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "features", Namespace: "default", Labels: managed}},
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "old-config", Namespace: "default", Labels: managed, Annotations: orphanedAt(2 * time.Hour)}},
+		&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unknown", Namespace: "team-b", Labels: managed, Annotations: orphanedAt(2 * time.Hour)}},
+	}
+	collector := &OrphanCollector{
+		Client:      fake.NewClientBuilder().WithScheme(scheme.Scheme).WithObjects(objects...).Build(),
+		GracePeriod: time.Hour,
+	}
+
+	if err := collector.Collect(context.Background(), now); err != nil {
+		t.Fatalf("Collect failed: %v", err)
+	}
+
+	tests := []struct {
+		obj        client.Object
+		deleted    bool
+		orphanedAt string
+	}{
+		{obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "in-use", Namespace: "default"}}},
+		{obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "reused", Namespace: "default"}}},
+		{obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "new-orphan", Namespace: "default"}}, orphanedAt: now.Format(time.RFC3339)},
+		{obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "recent-orphan", Namespace: "default"}}, orphanedAt: now.Add(-10 * time.Minute).Format(time.RFC3339)},
+		{obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "old-orphan", Namespace: "default"}}, deleted: true},
+		{obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Namespace: "default"}}, orphanedAt: now.Add(-2 * time.Hour).Format(time.RFC3339)},
+		{obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "features", Namespace: "default"}}, deleted: true},
+		{obj: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "features", Namespace: "default"}}},
+		{obj: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "old-config", Namespace: "default"}}, deleted: true},
+		{obj: &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "unknown", Namespace: "team-b"}}, orphanedAt: now.Add(-2 * time.Hour).Format(time.RFC3339)},
+	}
+	for _, tt := range tests {
+		name := client.ObjectKeyFromObject(tt.obj).String()
+		err := collector.Get(context.Background(), client.ObjectKeyFromObject(tt.obj), tt.obj)
+		if tt.deleted {
+			if err == nil {
+				t.Errorf("Expected %T %s to be deleted", tt.obj, name)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected %T %s to exist: %v", tt.obj, name, err)
+			continue
+		}
+		if got := tt.obj.GetAnnotations()[OrphanedAtAnnotation]; got != tt.orphanedAt {
+			t.Errorf("Expected %T %s orphaned-at %q, got %q", tt.obj, name, tt.orphanedAt, got)
+		}
+	}
+}
+
+func TestOrphanCollector_StartRejectsNonPositiveDurations(t *testing.T) {
+	tests := []struct {
+		name        string
+		interval    time.Duration
+		gracePeriod time.Duration
+	}{
+		{name: "zero interval", interval: 0, gracePeriod: time.Hour},
+		{name: "negative interval", interval: -time.Minute, gracePeriod: time.Hour},
+		{name: "zero grace period", interval: time.Minute, gracePeriod: 0},
+		{name: "negative grace period", interval: time.Minute, gracePeriod: -time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := &OrphanCollector{Interval: tt.interval, GracePeriod: tt.gracePeriod}
+			if err := c.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "must be positive") {
+				t.Errorf("Start() error = %v, want a positive duration error", err)
+			}
+		})
+	}
+}
@@ -4,22 +4,31 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"slices"
+	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	"github.com/codnod/jasm/internal/annotation"
 	"github.com/codnod/jasm/internal/events"
+	"github.com/codnod/jasm/internal/mapping"
 	"github.com/codnod/jasm/internal/provider"
 )
 
@@ -29,8 +38,21 @@ type PodSecretReconciler struct {
 	Scheme           *runtime.Scheme
 	Recorder         record.EventRecorder
 	ProviderRegistry *provider.ProviderRegistry
+	// OwnerReferences makes the top-level controller of each pod (e.g. its
+	// Deployment) an owner of the objects written for it, so that
+	// Kubernetes garbage-collects them once all their owners are deleted.
+	OwnerReferences bool
+	// APIReader reads the owners of pods without caching them. Defaults to
+	// the client.
+	APIReader client.Reader
+	// PodEvents, if set, enqueues the pods sent by the store reconcilers
+	// once a store they use is registered.
+	PodEvents <-chan event.GenericEvent
 }
 
+// maxOwnerDepth bounds the owner chain followed from a pod.
+const maxOwnerDepth = 5
+
 const (
 	// AnnotationKey is the annotation key for secret sync configuration.
 	AnnotationKey = "jasm.codnod.io/secret-sync"
@@ -38,16 +60,37 @@ const (
 	ManagedByLabel = "app.kubernetes.io/managed-by"
 	// ManagedByValue is the value for the managed-by label.
 	ManagedByValue = "jasm"
-	// SourcePathAnnotation tracks the external source path.
+	// SourcePathAnnotation tracks the external source paths, comma-separated
+	// when a secret is merged from several sources.
 	SourcePathAnnotation = "jasm.codnod.io/source-path"
 	// SyncedAtAnnotation tracks the last sync timestamp.
 	SyncedAtAnnotation = "jasm.codnod.io/synced-at"
+	// SourceVersionAnnotation tracks the provider versions that were synced,
+	// for providers that report one, in the order of the source paths.
+	SourceVersionAnnotation = "jasm.codnod.io/source-version"
+	// CustomLabelsAnnotation lists the labels set from the sync annotation,
+	// so that labels dropped from it are removed from the secret.
+	CustomLabelsAnnotation = "jasm.codnod.io/custom-labels"
+	// CustomAnnotationsAnnotation lists the annotations set from the sync
+	// annotation, so that annotations dropped from it are removed.
+	CustomAnnotationsAnnotation = "jasm.codnod.io/custom-annotations"
+	// OrphanedAtAnnotation records when the OrphanCollector found an object
+	// no longer referenced by any pod.
+	OrphanedAtAnnotation = "jasm.codnod.io/orphaned-at"
 )
 
+// sourceSecretIndex is the field index of pods by the Secrets their sync
+// entries may copy with the kubernetes provider (see sourceSecretIndexValues).
+const sourceSecretIndex = "jasm.codnod.io/source-secret"
+
 // Reconcile handles pod events and synchronizes secrets.
 // +kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=secrets,verbs=get;list;create;update;patch
+// +kubebuilder:rbac:groups="",resources=configmaps,verbs=get;list;watch;create;update;patch
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
 // +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=apps,resources=replicasets;deployments;statefulsets;daemonsets,verbs=get
+// +kubebuilder:rbac:groups=batch,resources=jobs;cronjobs,verbs=get
 func (r *PodSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := log.FromContext(ctx)
 
@@ -65,125 +108,461 @@ func (r *PodSecretReconciler) Reconcile(ctx context.Context, req ctrl.Request) (
 		return ctrl.Result{}, nil
 	}
 
-	syncRequest, err := annotation.ParseAnnotation(annotationValue, pod.Namespace, pod.Name, pod.UID)
+	syncRequests, err := annotation.ParseAnnotations(annotationValue, pod.Namespace, pod.Name, pod.UID)
 	if err != nil {
 		log.Error(err, "Failed to parse annotation", "annotation", annotationValue)
 		events.EmitAnnotationInvalid(r.Recorder, &pod, err)
 		return ctrl.Result{}, nil
 	}
 
+	// Sync every entry even when an earlier one fails, so that one broken
+	// entry does not hold back the others; failed entries are retried.
+	var errs []error
+	for _, syncRequest := range syncRequests {
+		if err := r.syncSecret(ctx, &pod, syncRequest); err != nil {
+			errs = append(errs, fmt.Errorf("secret %s: %w", syncRequest.SecretName, err))
+		}
+	}
+	if len(errs) > 0 {
+		log.Info("Some secrets failed to sync", "entries", len(syncRequests), "failed", len(errs))
+		return ctrl.Result{}, errors.Join(errs...)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// syncSecret synchronizes the Kubernetes secret of a single sync entry and
+// records the outcome as a pod event. Errors are returned only when the entry
+// should be retried; invalid entries are reported and skipped.
+func (r *PodSecretReconciler) syncSecret(ctx context.Context, pod *corev1.Pod, syncRequest *annotation.SecretSyncRequest) error {
+	log := log.FromContext(ctx).WithValues("secret", syncRequest.SecretName)
+
 	if syncRequest.Namespace != pod.Namespace {
 		err := fmt.Errorf("annotation namespace mismatch: annotation specifies %s but pod is in %s", syncRequest.Namespace, pod.Namespace)
 		log.Error(err, "Namespace validation failed")
-		events.EmitAnnotationInvalid(r.Recorder, &pod, err)
-		return ctrl.Result{}, nil
+		events.EmitAnnotationInvalid(r.Recorder, pod, err)
+		return nil
 	}
 
-	secretProvider := r.ProviderRegistry.Get(syncRequest.Provider)
-	if secretProvider == nil {
-		err := fmt.Errorf("unsupported provider: %s", syncRequest.Provider)
-		log.Error(err, "Provider not found", "provider", syncRequest.Provider)
-		events.EmitProviderNotFound(r.Recorder, &pod, syncRequest.Provider)
-		return ctrl.Result{}, nil
+	secretProviders := make([]provider.SecretProvider, 0, len(syncRequest.Sources))
+	for _, source := range syncRequest.Sources {
+		secretProvider := r.ProviderRegistry.Resolve(pod.Namespace, source.Provider)
+		if secretProvider == nil {
+			err := fmt.Errorf("unsupported provider: %s", source.Provider)
+			log.Error(err, "Provider not found", "provider", source.Provider)
+			events.EmitProviderNotFound(r.Recorder, pod, source.Provider)
+			return nil
+		}
+		if err := provider.CheckFetchOptions(secretProvider, fetchOptions(source)); err != nil {
+			err = fmt.Errorf("source %s: %w", source.Provider, err)
+			log.Error(err, "Invalid source settings", "provider", source.Provider)
+			events.EmitAnnotationInvalid(r.Recorder, pod, err)
+			return nil
+		}
+		secretProviders = append(secretProviders, secretProvider)
 	}
 
-	log.Info("Fetching secret from provider", "provider", syncRequest.Provider, "path", syncRequest.SecretPath)
-	secretData, err := secretProvider.FetchSecret(ctx, syncRequest.SecretPath)
-	if err != nil {
-		log.Error(err, "Failed to fetch secret", "provider", syncRequest.Provider, "path", syncRequest.SecretPath)
-		events.EmitSecretFetchFailed(r.Recorder, &pod, syncRequest.Provider, syncRequest.SecretPath, err)
-		return ctrl.Result{Requeue: true}, err
+	// Fetch every source before writing anything, so that a failing source
+	// never leaves a partially merged secret behind.
+	fetched := make([]map[string][]byte, 0, len(syncRequest.Sources))
+	providerNames := make([]string, 0, len(syncRequest.Sources))
+	paths := make([]string, 0, len(syncRequest.Sources))
+	versions := make([]string, 0, len(syncRequest.Sources))
+	versioned := false
+	var sourceType corev1.SecretType
+	for i, source := range syncRequest.Sources {
+		log.Info("Fetching secret from provider", "provider", source.Provider, "path", source.Path)
+		fetchCtx := provider.WithRequestNamespace(ctx, pod.Namespace)
+		fetchCtx = provider.WithFetchOptions(fetchCtx, fetchOptions(source))
+		secretValue, err := provider.Fetch(fetchCtx, secretProviders[i], source.Path)
+		if err != nil {
+			log.Error(err, "Failed to fetch secret", "provider", source.Provider, "path", source.Path)
+			events.EmitSecretFetchFailed(r.Recorder, pod, source.Provider, source.Path, err)
+			return err
+		}
+
+		fetched = append(fetched, secretValue.Data)
+		if !slices.Contains(providerNames, source.Provider) {
+			providerNames = append(providerNames, source.Provider)
+		}
+		paths = append(paths, source.Path)
+		versions = append(versions, secretValue.Version)
+		versioned = versioned || secretValue.Version != ""
+		sourceType = secretValue.Type
+	}
+
+	// A copy of a single Kubernetes Secret keeps its type unless the entry
+	// sets one.
+	secretType := syncRequest.Type
+	if secretType == "" && len(syncRequest.Sources) == 1 {
+		secretType = sourceType
+	}
+	if secretType == "" {
+		secretType = corev1.SecretTypeOpaque
+	}
+
+	// Merge the sources, then apply key mappings if provided; mapped keys
+	// may be dotted or JSONPath paths into nested JSON values.
+	merged := mapping.Merge(fetched...)
+	targetData, missing := mapping.Apply(merged, syncRequest.KeyMapping, syncRequest.Flatten)
+	for _, key := range missing {
+		log.Info("Secret key not found in fetched secret", "key", key)
 	}
 
-	secret := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      syncRequest.SecretName,
-			Namespace: syncRequest.Namespace,
-		},
+	if len(syncRequest.Templates) > 0 {
+		rendered, err := mapping.Render(merged, syncRequest.Templates)
+		if err != nil {
+			log.Error(err, "Failed to render templates")
+			events.EmitTemplateRenderFailed(r.Recorder, pod, syncRequest.SecretName, err)
+			return err
+		}
+		for key, value := range rendered {
+			targetData[key] = value
+		}
 	}
 
-	err = r.Get(ctx, client.ObjectKeyFromObject(secret), secret)
-	secretExists := !apierrors.IsNotFound(err)
+	objectMeta := metav1.ObjectMeta{
+		Name:      syncRequest.SecretName,
+		Namespace: syncRequest.Namespace,
+	}
+	var target client.Object
+	switch syncRequest.Kind {
+	case annotation.KindConfigMap:
+		target = &corev1.ConfigMap{ObjectMeta: objectMeta}
+	default:
+		var err error
+		targetData, err = mapping.PrepareTypedData(secretType, targetData)
+		if err != nil {
+			log.Error(err, "Secret data does not match its type", "type", secretType)
+			events.EmitSecretSyncFailed(r.Recorder, pod, syncRequest.SecretName, err)
+			return err
+		}
+		target = &corev1.Secret{ObjectMeta: objectMeta}
+	}
+
+	err := r.Get(ctx, client.ObjectKeyFromObject(target), target)
+	targetExists := !apierrors.IsNotFound(err)
 	if err != nil && !apierrors.IsNotFound(err) {
-		log.Error(err, "Failed to check if secret exists", "secret", syncRequest.SecretName)
-		return ctrl.Result{}, err
+		log.Error(err, "Failed to check if target exists", "kind", syncRequest.Kind)
+		return err
+	}
+
+	// Never take over an object JASM did not create.
+	if targetExists && target.GetLabels()[ManagedByLabel] != ManagedByValue {
+		err := fmt.Errorf("%s %s exists and is not managed by JASM (missing label %s=%s)",
+			syncRequest.Kind, syncRequest.SecretName, ManagedByLabel, ManagedByValue)
+		log.Error(err, "Refusing to overwrite unmanaged object")
+		events.EmitSecretSyncFailed(r.Recorder, pod, syncRequest.SecretName, err)
+		return nil
+	}
+
+	labels := target.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	annotations := target.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	// Custom metadata goes first so that JASM's own keys always win.
+	applyCustomMetadata(labels, annotations, CustomLabelsAnnotation, syncRequest.Labels)
+	applyCustomMetadata(annotations, annotations, CustomAnnotationsAnnotation, syncRequest.Annotations)
+	labels[ManagedByLabel] = ManagedByValue
+
+	annotations[SourcePathAnnotation] = strings.Join(paths, ",")
+	annotations[SyncedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if versioned {
+		annotations[SourceVersionAnnotation] = strings.Join(versions, ",")
+	} else {
+		delete(annotations, SourceVersionAnnotation)
+	}
+	delete(annotations, OrphanedAtAnnotation)
+	target.SetLabels(labels)
+	target.SetAnnotations(annotations)
+
+	if r.OwnerReferences {
+		ownerRef, err := r.topLevelOwner(ctx, pod)
+		if err != nil {
+			log.Error(err, "Failed to resolve the pod's top-level controller")
+			return err
+		}
+		addOwnerReference(target, ownerRef)
 	}
 
-	secretStringData := make(map[string]string)
+	switch target := target.(type) {
+	case *corev1.Secret:
+		// The type of a secret cannot be changed once it is created.
+		if targetExists && target.Type != "" && target.Type != secretType {
+			err := fmt.Errorf("secret has type %s, cannot change it to %s; delete the secret to recreate it", target.Type, secretType)
+			log.Error(err, "Secret type mismatch")
+			events.EmitSecretSyncFailed(r.Recorder, pod, syncRequest.SecretName, err)
+			return nil
+		}
+
+		// Write Data rather than StringData so binary values survive and keys
+		// removed at the source are dropped.
+		target.Data = targetData
+		target.StringData = nil
+		target.Type = secretType
 
-	// Apply key mappings if provided
-	if len(syncRequest.KeyMapping) > 0 {
-		for kubernetesKey, awsSecretKey := range syncRequest.KeyMapping {
-			if value, exists := secretData[awsSecretKey]; exists {
-				secretStringData[kubernetesKey] = value
-				log.V(1).Info("Mapped secret key", "awsKey", awsSecretKey, "kubernetesKey", kubernetesKey)
-			} else {
-				log.Info("AWS secret key not found in fetched secret", "awsKey", awsSecretKey)
+	case *corev1.ConfigMap:
+		// ConfigMap data must be UTF-8; anything else goes to BinaryData.
+		target.Data = make(map[string]string, len(targetData))
+		target.BinaryData = nil
+		for key, value := range targetData {
+			if utf8.Valid(value) {
+				target.Data[key] = string(value)
+				continue
 			}
+			if target.BinaryData == nil {
+				target.BinaryData = make(map[string][]byte)
+			}
+			target.BinaryData[key] = value
+		}
+	}
+
+	if targetExists {
+		log.Info("Updating existing target", "kind", syncRequest.Kind, "namespace", syncRequest.Namespace)
+		if err := r.Update(ctx, target); err != nil {
+			log.Error(err, "Failed to update target", "kind", syncRequest.Kind)
+			return err
 		}
+		log.Info("Target updated successfully", "kind", syncRequest.Kind)
 	} else {
-		// If no key mappings, copy all keys as-is
-		for k, v := range secretData {
-			secretStringData[k] = v
+		log.Info("Creating new target", "kind", syncRequest.Kind, "namespace", syncRequest.Namespace)
+		if err := r.Create(ctx, target); err != nil {
+			log.Error(err, "Failed to create target", "kind", syncRequest.Kind)
+			return err
 		}
+		log.Info("Target created successfully", "kind", syncRequest.Kind)
 	}
 
-	if secret.Labels == nil {
-		secret.Labels = make(map[string]string)
+	events.EmitSecretSyncSuccess(r.Recorder, pod, syncRequest.SecretName, strings.Join(providerNames, ", "), strings.Join(paths, ", "))
+
+	return nil
+}
+
+// topLevelOwner returns a reference to the top-level controller of pod,
+// following controller owner references (Pod, ReplicaSet, Deployment, ...).
+// A pod without a controller is its own top-level owner. When an owner
+// cannot be read, e.g. a custom resource JASM has no access to, the chain
+// stops at the last owner that could be read.
+func (r *PodSecretReconciler) topLevelOwner(ctx context.Context, pod *corev1.Pod) (metav1.OwnerReference, error) {
+	reader := r.APIReader
+	if reader == nil {
+		reader = r.Client
 	}
-	secret.Labels[ManagedByLabel] = ManagedByValue
 
-	if secret.Annotations == nil {
-		secret.Annotations = make(map[string]string)
+	owner := metav1.OwnerReference{
+		APIVersion: "v1",
+		Kind:       "Pod",
+		Name:       pod.Name,
+		UID:        pod.UID,
 	}
-	secret.Annotations[SourcePathAnnotation] = syncRequest.SecretPath
-	secret.Annotations[SyncedAtAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	controllerRef := metav1.GetControllerOf(pod)
+	for depth := 0; controllerRef != nil && depth < maxOwnerDepth; depth++ {
+		owner = metav1.OwnerReference{
+			APIVersion: controllerRef.APIVersion,
+			Kind:       controllerRef.Kind,
+			Name:       controllerRef.Name,
+			UID:        controllerRef.UID,
+		}
 
-	secret.StringData = secretStringData
-	secret.Type = corev1.SecretTypeOpaque
+		var ownerMeta metav1.PartialObjectMetadata
+		ownerMeta.SetGroupVersionKind(schema.FromAPIVersionAndKind(controllerRef.APIVersion, controllerRef.Kind))
+		if err := reader.Get(ctx, client.ObjectKey{Namespace: pod.Namespace, Name: controllerRef.Name}, &ownerMeta); err != nil {
+			if apierrors.IsNotFound(err) {
+				return metav1.OwnerReference{}, fmt.Errorf("owner %s %s not found: %w", controllerRef.Kind, controllerRef.Name, err)
+			}
+			log.FromContext(ctx).V(1).Info("Cannot read pod owner, stopping there", "kind", controllerRef.Kind, "name", controllerRef.Name, "error", err.Error())
+			break
+		}
+		controllerRef = metav1.GetControllerOf(&ownerMeta)
+	}
+	return owner, nil
+}
 
-	if secretExists {
-		log.Info("Updating existing secret", "secret", syncRequest.SecretName, "namespace", syncRequest.Namespace)
-		if err := r.Update(ctx, secret); err != nil {
-			log.Error(err, "Failed to update secret", "secret", syncRequest.SecretName)
-			return ctrl.Result{}, err
+// addOwnerReference adds ownerRef to the owners of obj unless it already has
+// it. The reference is not a controller reference, so that objects shared by
+// several workloads are only deleted once all of them are.
+func addOwnerReference(obj client.Object, ownerRef metav1.OwnerReference) {
+	ownerRefs := obj.GetOwnerReferences()
+	for _, existing := range ownerRefs {
+		if existing.UID == ownerRef.UID {
+			return
 		}
-		log.Info("Secret updated successfully", "secret", syncRequest.SecretName)
-	} else {
-		log.Info("Creating new secret", "secret", syncRequest.SecretName, "namespace", syncRequest.Namespace)
-		if err := r.Create(ctx, secret); err != nil {
-			log.Error(err, "Failed to create secret", "secret", syncRequest.SecretName)
-			return ctrl.Result{}, err
+	}
+	obj.SetOwnerReferences(append(ownerRefs, ownerRef))
+}
+
+// applyCustomMetadata sets the custom labels or annotations in desired on
+// target and removes the ones set by a previous sync that are no longer
+// desired. The keys that were set are recorded in annotations[trackingKey].
+func applyCustomMetadata(target, annotations map[string]string, trackingKey string, desired map[string]string) {
+	if previous := annotations[trackingKey]; previous != "" {
+		for _, key := range strings.Split(previous, ",") {
+			if _, keep := desired[key]; !keep {
+				delete(target, key)
+			}
 		}
-		log.Info("Secret created successfully", "secret", syncRequest.SecretName)
 	}
 
-	events.EmitSecretSyncSuccess(r.Recorder, &pod, syncRequest.SecretName, syncRequest.Provider, syncRequest.SecretPath)
+	keys := make([]string, 0, len(desired))
+	for key, value := range desired {
+		target[key] = value
+		keys = append(keys, key)
+	}
+	if len(keys) == 0 {
+		delete(annotations, trackingKey)
+		return
+	}
+	sort.Strings(keys)
+	annotations[trackingKey] = strings.Join(keys, ",")
+}
 
-	return ctrl.Result{}, nil
+// fetchOptions returns the provider settings of a source.
+func fetchOptions(source annotation.SecretSource) provider.FetchOptions {
+	return provider.FetchOptions{
+		Region:       source.Region,
+		Version:      source.Version,
+		VersionStage: source.VersionStage,
+		Format:       source.Format,
+		TextKey:      source.TextKey,
+	}
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *PodSecretReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Pod{}, sourceSecretIndex, sourceSecretIndexValues); err != nil {
+		return fmt.Errorf("failed to index pods by source secret: %w", err)
+	}
+
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&corev1.Pod{}).
 		Watches(
 			&corev1.Secret{},
 			handler.EnqueueRequestsFromMapFunc(r.findPodsForSecret),
 		).
-		Complete(r)
+		Watches(
+			&corev1.ConfigMap{},
+			handler.EnqueueRequestsFromMapFunc(r.findPodsForConfigMap),
+		)
+	if r.PodEvents != nil {
+		b = b.WatchesRawSource(source.Channel(r.PodEvents, &handler.EnqueueRequestForObject{}))
+	}
+	return b.Complete(r)
 }
 
 // findPodsForSecret finds all pods that reference a deleted secret.
 // This ensures that when a Caronte-managed secret is deleted, the pods
-// that need it are reconciled and the secret is recreated.
+// that need it are reconciled and the secret is recreated. Pods copying the
+// secret with the kubernetes provider are reconciled too, so that copies
+// follow changes to their source.
 func (r *PodSecretReconciler) findPodsForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
-	if secret.GetLabels()[ManagedByLabel] != ManagedByValue {
+	requests := r.findPodsForTarget(ctx, secret, annotation.KindSecret)
+	for _, request := range r.findPodsForSourceSecret(ctx, secret) {
+		if !slices.Contains(requests, request) {
+			requests = append(requests, request)
+		}
+	}
+	return requests
+}
+
+// sourceSecretIndexValues returns the "namespace/name" paths of the sources
+// of a pod's sync entries that could name a Secret for the kubernetes
+// provider, for the sourceSecretIndex field index. Whether a source actually
+// uses a kubernetes provider depends on the registry and is checked when the
+// index is queried.
+func sourceSecretIndexValues(obj client.Object) []string {
+	value, hasAnnotation := obj.GetAnnotations()[AnnotationKey]
+	if !hasAnnotation {
+		return nil
+	}
+	syncRequests, err := annotation.ParseAnnotations(value, obj.GetNamespace(), obj.GetName(), obj.GetUID())
+	if err != nil {
+		return nil
+	}
+
+	var values []string
+	for _, syncRequest := range syncRequests {
+		for _, source := range syncRequest.Sources {
+			namespace, name, err := provider.ParseKubernetesSecretPath(source.Path)
+			if err != nil {
+				continue
+			}
+			if value := namespace + "/" + name; !slices.Contains(values, value) {
+				values = append(values, value)
+			}
+		}
+	}
+	return values
+}
+
+// findPodsForSourceSecret finds all pods with a sync entry copying secret
+// through a kubernetes provider. Only the pods listing the secret in the
+// sourceSecretIndex are parsed.
+func (r *PodSecretReconciler) findPodsForSourceSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	var podList corev1.PodList
+	if err := r.List(ctx, &podList, client.MatchingFields{
+		sourceSecretIndex: secret.GetNamespace() + "/" + secret.GetName(),
+	}); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, pod := range podList.Items {
+		syncRequests, err := annotation.ParseAnnotations(
+			pod.Annotations[AnnotationKey],
+			pod.Namespace,
+			pod.Name,
+			pod.UID,
+		)
+		if err != nil {
+			continue
+		}
+
+		if r.copiesSecret(&pod, syncRequests, secret) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(&pod),
+			})
+		}
+	}
+
+	return requests
+}
+
+// copiesSecret reports whether a source of syncRequests reads secret
+// through a kubernetes provider.
+func (r *PodSecretReconciler) copiesSecret(pod *corev1.Pod, syncRequests []*annotation.SecretSyncRequest, secret client.Object) bool {
+	for _, syncRequest := range syncRequests {
+		for _, source := range syncRequest.Sources {
+			namespace, name, err := provider.ParseKubernetesSecretPath(source.Path)
+			if err != nil || namespace != secret.GetNamespace() || name != secret.GetName() {
+				continue
+			}
+			if _, ok := r.ProviderRegistry.Resolve(pod.Namespace, source.Provider).(*provider.KubernetesSecretProvider); ok {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// findPodsForConfigMap finds all pods that reference a deleted ConfigMap,
+// like findPodsForSecret.
+func (r *PodSecretReconciler) findPodsForConfigMap(ctx context.Context, configMap client.Object) []reconcile.Request {
+	return r.findPodsForTarget(ctx, configMap, annotation.KindConfigMap)
+}
+
+// findPodsForTarget finds all pods with a sync entry writing the managed
+// object target of the given kind.
+func (r *PodSecretReconciler) findPodsForTarget(ctx context.Context, target client.Object, kind string) []reconcile.Request {
+	if target.GetLabels()[ManagedByLabel] != ManagedByValue {
 		return nil
 	}
 
 	var podList corev1.PodList
-	if err := r.List(ctx, &podList, client.InNamespace(secret.GetNamespace())); err != nil {
+	if err := r.List(ctx, &podList, client.InNamespace(target.GetNamespace())); err != nil {
 		return nil
 	}
 
@@ -193,7 +572,7 @@ func (r *PodSecretReconciler) findPodsForSecret(ctx context.Context, secret clie
 			continue
 		}
 
-		syncRequest, err := annotation.ParseAnnotation(
+		syncRequests, err := annotation.ParseAnnotations(
 			pod.Annotations[AnnotationKey],
 			pod.Namespace,
 			pod.Name,
@@ -203,10 +582,13 @@ func (r *PodSecretReconciler) findPodsForSecret(ctx context.Context, secret clie
 			continue
 		}
 
-		if syncRequest.SecretName == secret.GetName() {
-			requests = append(requests, reconcile.Request{
-				NamespacedName: client.ObjectKeyFromObject(&pod),
-			})
+		for _, syncRequest := range syncRequests {
+			if syncRequest.Kind == kind && syncRequest.SecretName == target.GetName() {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: client.ObjectKeyFromObject(&pod),
+				})
+				break
+			}
 		}
 	}
 
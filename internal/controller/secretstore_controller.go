@@ -0,0 +1,364 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	jasmv1alpha1 "github.com/codnod/jasm/api/v1alpha1"
+	"github.com/codnod/jasm/internal/annotation"
+	"github.com/codnod/jasm/internal/provider"
+)
+
+// namespacedStoreDeniedTypes are provider types a namespaced SecretStore may
+// not use: they read the controller's filesystem or hand the controller's
+// cloud identity to a configurable endpoint.
+var namespacedStoreDeniedTypes = map[string]bool{
+	"file":              true,
+	"sops":              true,
+	"gcp-secretmanager": true,
+}
+
+// namespacedStoreDeniedSettings are provider settings a namespaced
+// SecretStore may not set, because they make the controller assume roles or
+// present its own credentials on the store's behalf.
+var namespacedStoreDeniedSettings = []string{
+	"roleArn",
+	"externalId",
+	"kubernetesRole",
+	"kubernetesMountPath",
+	"serviceAccountTokenPath",
+	"federatedTokenFile",
+	"namespaceRoles",
+	"namespaceRoleAnnotation",
+	"requireNamespaceRole",
+}
+
+// ClusterSecretStoreReconciler keeps the provider registry in sync with
+// ClusterSecretStore objects.
+type ClusterSecretStoreReconciler struct {
+	client.Client
+	Scheme           *runtime.Scheme
+	ProviderRegistry *provider.ProviderRegistry
+	// Defaults supplies the settings a store does not set, as for the
+	// provider configuration file.
+	Defaults provider.RegistryOptions
+	// PodEvents, if set, receives the pods using a store once it is
+	// registered, so that they are synced without waiting for a pod update.
+	PodEvents chan<- event.GenericEvent
+}
+
+// Reconcile creates, replaces or removes the provider backing a ClusterSecretStore.
+// +kubebuilder:rbac:groups=jasm.codnod.io,resources=clustersecretstores,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jasm.codnod.io,resources=clustersecretstores/status,verbs=get;update;patch
+func (r *ClusterSecretStoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	var store jasmv1alpha1.ClusterSecretStore
+	if err := r.Get(ctx, req.NamespacedName, &store); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			log.Info("ClusterSecretStore deleted, removing provider", "store", req.Name)
+			r.ProviderRegistry.RemoveStore("", req.Name)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	var secretProvider provider.SecretProvider
+	var err error
+	if r.ProviderRegistry.Registered(store.Name) {
+		// A store shadowing a startup provider would silently take over
+		// every pod using that provider.
+		err = fmt.Errorf("name %q is already used by a provider configured at startup", store.Name)
+	} else {
+		secretProvider, err = newStoreProvider(ctx, r.Client, store.Name, "", store.Spec, r.Defaults)
+	}
+	if err != nil {
+		log.Error(err, "Failed to create provider for ClusterSecretStore", "store", store.Name)
+		r.ProviderRegistry.RemoveStore("", store.Name)
+	} else {
+		log.Info("Registered provider for ClusterSecretStore", "store", store.Name, "type", store.Spec.Type)
+		r.ProviderRegistry.SetStore("", store.Name, secretProvider)
+		if err := enqueuePodsUsingStore(ctx, r.Client, r.PodEvents, "", store.Name); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if updateErr := updateStoreStatus(ctx, r.Client, &store, &store.Status, err); updateErr != nil {
+		return ctrl.Result{}, updateErr
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterSecretStoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&jasmv1alpha1.ClusterSecretStore{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.findStoresForSecret),
+		).
+		Complete(r)
+}
+
+// findStoresForSecret finds the ClusterSecretStores reading credentials from
+// a Secret, so that rotated credentials are picked up.
+func (r *ClusterSecretStoreReconciler) findStoresForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	var storeList jasmv1alpha1.ClusterSecretStoreList
+	if err := r.List(ctx, &storeList); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, store := range storeList.Items {
+		if referencesSecret(store.Spec, store.Namespace, secret) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(&store),
+			})
+		}
+	}
+	return requests
+}
+
+// enqueuePodsUsingStore sends the pods with a sync entry reading from the
+// store name to podEvents. Pods of every namespace are considered for a
+// ClusterSecretStore (empty namespace).
+func enqueuePodsUsingStore(ctx context.Context, c client.Reader, podEvents chan<- event.GenericEvent, namespace, name string) error {
+	if podEvents == nil {
+		return nil
+	}
+
+	var podList corev1.PodList
+	if err := c.List(ctx, &podList, client.InNamespace(namespace)); err != nil {
+		return fmt.Errorf("failed to list pods using store %s: %w", name, err)
+	}
+
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if _, hasAnnotation := pod.Annotations[AnnotationKey]; !hasAnnotation {
+			continue
+		}
+
+		syncRequests, err := annotation.ParseAnnotations(
+			pod.Annotations[AnnotationKey],
+			pod.Namespace,
+			pod.Name,
+			pod.UID,
+		)
+		if err != nil || !usesProvider(syncRequests, name) {
+			continue
+		}
+
+		select {
+		case podEvents <- event.GenericEvent{Object: pod}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// usesProvider reports whether a source of syncRequests names the provider.
+func usesProvider(syncRequests []*annotation.SecretSyncRequest, name string) bool {
+	for _, syncRequest := range syncRequests {
+		for _, source := range syncRequest.Sources {
+			if source.Provider == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SecretStoreReconciler keeps the provider registry in sync with namespaced
+// SecretStore objects.
+type SecretStoreReconciler struct {
+	client.Client
+	Scheme           *runtime.Scheme
+	ProviderRegistry *provider.ProviderRegistry
+	// KubeClient reads Secrets for stores of type "kubernetes".
+	KubeClient client.Reader
+	// AWS supplies the per-namespace role settings of AWS stores, so that a
+	// SecretStore cannot sidestep the role of its namespace.
+	AWS provider.AWSConfig
+	// PodEvents, if set, receives the pods using a store once it is
+	// registered, like ClusterSecretStoreReconciler.PodEvents.
+	PodEvents chan<- event.GenericEvent
+}
+
+// Reconcile creates, replaces or removes the provider backing a SecretStore.
+// +kubebuilder:rbac:groups=jasm.codnod.io,resources=secretstores,verbs=get;list;watch
+// +kubebuilder:rbac:groups=jasm.codnod.io,resources=secretstores/status,verbs=get;update;patch
+func (r *SecretStoreReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := log.FromContext(ctx)
+
+	var store jasmv1alpha1.SecretStore
+	if err := r.Get(ctx, req.NamespacedName, &store); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			log.Info("SecretStore deleted, removing provider", "store", req.NamespacedName)
+			r.ProviderRegistry.RemoveStore(req.Namespace, req.Name)
+		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	// Namespaced stores never inherit the controller's own settings, except
+	// for the per-namespace AWS roles that confine them.
+	defaults := provider.RegistryOptions{
+		KubeClient: r.KubeClient,
+		AWS: provider.AWSConfig{
+			NamespaceRoles:          r.AWS.NamespaceRoles,
+			NamespaceRoleAnnotation: r.AWS.NamespaceRoleAnnotation,
+			RequireNamespaceRole:    r.AWS.RequireNamespaceRole,
+			Client:                  r.AWS.Client,
+		},
+	}
+	secretProvider, err := newStoreProvider(ctx, r.Client, store.Name, store.Namespace, store.Spec, defaults)
+	if err != nil {
+		log.Error(err, "Failed to create provider for SecretStore", "store", req.NamespacedName)
+		r.ProviderRegistry.RemoveStore(store.Namespace, store.Name)
+	} else {
+		log.Info("Registered provider for SecretStore", "store", req.NamespacedName, "type", store.Spec.Type)
+		r.ProviderRegistry.SetStore(store.Namespace, store.Name, secretProvider)
+		if err := enqueuePodsUsingStore(ctx, r.Client, r.PodEvents, store.Namespace, store.Name); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	if updateErr := updateStoreStatus(ctx, r.Client, &store, &store.Status, err); updateErr != nil {
+		return ctrl.Result{}, updateErr
+	}
+	return ctrl.Result{}, nil
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *SecretStoreReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&jasmv1alpha1.SecretStore{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(
+			&corev1.Secret{},
+			handler.EnqueueRequestsFromMapFunc(r.findStoresForSecret),
+		).
+		Complete(r)
+}
+
+// findStoresForSecret finds the SecretStores reading credentials from a
+// Secret, so that rotated credentials are picked up.
+func (r *SecretStoreReconciler) findStoresForSecret(ctx context.Context, secret client.Object) []reconcile.Request {
+	var storeList jasmv1alpha1.SecretStoreList
+	if err := r.List(ctx, &storeList, client.InNamespace(secret.GetNamespace())); err != nil {
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, store := range storeList.Items {
+		if referencesSecret(store.Spec, store.Namespace, secret) {
+			requests = append(requests, reconcile.Request{
+				NamespacedName: client.ObjectKeyFromObject(&store),
+			})
+		}
+	}
+	return requests
+}
+
+// newStoreProvider creates the provider described by a store spec. namespace
+// is empty for a ClusterSecretStore; for a SecretStore it restricts the
+// provider types and settings that may be used, and is where referenced
+// Secrets are read from.
+func newStoreProvider(ctx context.Context, reader client.Reader, name, namespace string, spec jasmv1alpha1.SecretStoreSpec, defaults provider.RegistryOptions) (provider.SecretProvider, error) {
+	settings := make(map[string]interface{})
+	if spec.Config != nil && len(spec.Config.Raw) > 0 {
+		if err := json.Unmarshal(spec.Config.Raw, &settings); err != nil {
+			return nil, fmt.Errorf("invalid config: %w", err)
+		}
+	}
+
+	for setting, ref := range spec.SecretRefs {
+		secretNamespace := ref.Namespace
+		if namespace != "" {
+			secretNamespace = namespace
+		}
+		if secretNamespace == "" {
+			return nil, fmt.Errorf("secretRefs.%s: namespace is required", setting)
+		}
+
+		var secret corev1.Secret
+		if err := reader.Get(ctx, client.ObjectKey{Namespace: secretNamespace, Name: ref.Name}, &secret); err != nil {
+			return nil, fmt.Errorf("failed to read secret %s/%s: %w", secretNamespace, ref.Name, err)
+		}
+		value, ok := secret.Data[ref.Key]
+		if !ok {
+			return nil, fmt.Errorf("secret %s/%s has no key %s", secretNamespace, ref.Name, ref.Key)
+		}
+		settings[setting] = string(value)
+	}
+
+	displayName := name
+	if namespace != "" {
+		displayName = namespace + "/" + name
+		if namespacedStoreDeniedTypes[spec.Type] {
+			return nil, fmt.Errorf("provider type %s is only available to ClusterSecretStores", spec.Type)
+		}
+		for _, setting := range namespacedStoreDeniedSettings {
+			if _, set := settings[setting]; set {
+				return nil, fmt.Errorf("setting %s is only available to ClusterSecretStores", setting)
+			}
+		}
+	}
+
+	providerConfig := provider.ProviderConfig{Name: displayName, Type: spec.Type}
+	if err := providerConfig.Config.Encode(settings); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+	return provider.NewProviderFromConfig(ctx, providerConfig, defaults)
+}
+
+// referencesSecret reports whether a store spec reads credentials from secret.
+func referencesSecret(spec jasmv1alpha1.SecretStoreSpec, storeNamespace string, secret client.Object) bool {
+	for _, ref := range spec.SecretRefs {
+		refNamespace := ref.Namespace
+		if storeNamespace != "" {
+			refNamespace = storeNamespace
+		}
+		if ref.Name == secret.GetName() && refNamespace == secret.GetNamespace() {
+			return true
+		}
+	}
+	return false
+}
+
+// updateStoreStatus records the outcome of creating a store's provider in
+// its Ready condition.
+func updateStoreStatus(ctx context.Context, c client.Client, store client.Object, status *jasmv1alpha1.SecretStoreStatus, providerErr error) error {
+	condition := metav1.Condition{
+		Type:               jasmv1alpha1.SecretStoreReadyCondition,
+		Status:             metav1.ConditionTrue,
+		Reason:             "ProviderCreated",
+		Message:            "Provider is registered",
+		ObservedGeneration: store.GetGeneration(),
+	}
+	if providerErr != nil {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InvalidConfig"
+		condition.Message = providerErr.Error()
+	}
+
+	if !meta.SetStatusCondition(&status.Conditions, condition) {
+		return nil
+	}
+	if err := c.Status().Update(ctx, store); err != nil {
+		return fmt.Errorf("failed to update store status: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,232 @@
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	jasmv1alpha1 "github.com/codnod/jasm/api/v1alpha1"
+	"github.com/codnod/jasm/internal/provider"
+)
+
+func TestNewStoreProvider(t *testing.T) {
+	reader := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "connect", Namespace: "team-a"},
+			Data:       map[string][]byte{"token": []byte("connect-token")},
+		},
+	).Build()
+
+	tests := []struct {
+		name      string
+		namespace string
+		spec      jasmv1alpha1.SecretStoreSpec
+		wantErr   string
+	}{
+		{
+			name:      "SecretStore with secretRef",
+			namespace: "team-a",
+			spec: jasmv1alpha1.SecretStoreSpec{
+				Type:       "onepassword",
+				Config:     &runtime.RawExtension{Raw: []byte(`{"host":"http://connect:8080"}`)},
+				SecretRefs: map[string]jasmv1alpha1.SecretKeyReference{"token": {Name: "connect", Key: "token"}},
+			},
+		},
+		{
+			name: "ClusterSecretStore with secretRef",
+			spec: jasmv1alpha1.SecretStoreSpec{
+				Type:       "onepassword",
+				Config:     &runtime.RawExtension{Raw: []byte(`{"host":"http://connect:8080"}`)},
+				SecretRefs: map[string]jasmv1alpha1.SecretKeyReference{"token": {Namespace: "team-a", Name: "connect", Key: "token"}},
+			},
+		},
+		{
+			name: "ClusterSecretStore secretRef without namespace",
+			spec: jasmv1alpha1.SecretStoreSpec{
+				Type:       "onepassword",
+				SecretRefs: map[string]jasmv1alpha1.SecretKeyReference{"token": {Name: "connect", Key: "token"}},
+			},
+			wantErr: "namespace is required",
+		},
+		{
+			name:      "SecretStore reads its own namespace only",
+			namespace: "team-b",
+			spec: jasmv1alpha1.SecretStoreSpec{
+				Type:       "onepassword",
+				SecretRefs: map[string]jasmv1alpha1.SecretKeyReference{"token": {Namespace: "team-a", Name: "connect", Key: "token"}},
+			},
+			wantErr: "not found",
+		},
+		{
+			name:      "SecretStore cannot use file provider",
+			namespace: "team-a",
+			spec: jasmv1alpha1.SecretStoreSpec{
+				Type:   "file",
+				Config: &runtime.RawExtension{Raw: []byte(`{"root":"/"}`)},
+			},
+			wantErr: "only available to ClusterSecretStores",
+		},
+		{
+			name:      "SecretStore cannot assume roles",
+			namespace: "team-a",
+			spec: jasmv1alpha1.SecretStoreSpec{
+				Type:   "aws-secretsmanager",
+				Config: &runtime.RawExtension{Raw: []byte(`{"roleArn":"arn:aws:iam::111111111111:role/admin"}`)},
+			},
+			wantErr: "only available to ClusterSecretStores",
+		},
+		{
+			name:    "Unknown type",
+			spec:    jasmv1alpha1.SecretStoreSpec{Type: "keepass"},
+			wantErr: "unknown type",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newStoreProvider(context.Background(), reader, "store", tt.namespace, tt.spec, provider.RegistryOptions{})
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("newStoreProvider() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newStoreProvider() error = %v", err)
+			}
+			if got.Name() != tt.spec.Type {
+				t.Errorf("Name() = %q, want %q", got.Name(), tt.spec.Type)
+			}
+		})
+	}
+}
+
+// newStoreTestScheme returns a scheme with the core and JASM types.
+func newStoreTestScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	if err := jasmv1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	return scheme
+}
+
+func TestSecretStoreReconciler_EnqueuesPodsUsingStore(t *testing.T) {
+	scheme := newStoreTestScheme(t)
+
+	pod := func(namespace, name, providerName string) *corev1.Pod {
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: namespace,
+				Annotations: map[string]string{
+					AnnotationKey: "provider: " + providerName + "\npath: app/db\nsecretName: db-credentials\n",
+				},
+			},
+		}
+	}
+	store := &jasmv1alpha1.SecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "team-vault", Namespace: "team-a"},
+		Spec: jasmv1alpha1.SecretStoreSpec{
+			Type:       "onepassword",
+			Config:     &runtime.RawExtension{Raw: []byte(`{"host":"http://connect:8080"}`)},
+			SecretRefs: map[string]jasmv1alpha1.SecretKeyReference{"token": {Name: "connect", Key: "token"}},
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&jasmv1alpha1.SecretStore{}).
+		WithObjects(
+			store,
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "connect", Namespace: "team-a"},
+				Data:       map[string][]byte{"token": []byte("connect-token")},
+			},
+			pod("team-a", "uses-store", "team-vault"),
+			pod("team-a", "uses-other", "aws-secretsmanager"),
+			pod("team-b", "other-namespace", "team-vault"),
+		).
+		Build()
+
+	podEvents := make(chan event.GenericEvent, 10)
+	r := &SecretStoreReconciler{
+		Client:           c,
+		Scheme:           scheme,
+		ProviderRegistry: provider.NewProviderRegistry(),
+		KubeClient:       c,
+		PodEvents:        podEvents,
+	}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Namespace: "team-a", Name: "team-vault"},
+	}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if r.ProviderRegistry.Resolve("team-a", "team-vault") == nil {
+		t.Fatal("store provider was not registered")
+	}
+
+	close(podEvents)
+	var enqueued []string
+	for e := range podEvents {
+		enqueued = append(enqueued, e.Object.GetNamespace()+"/"+e.Object.GetName())
+	}
+	if len(enqueued) != 1 || enqueued[0] != "team-a/uses-store" {
+		t.Errorf("enqueued pods = %v, want [team-a/uses-store]", enqueued)
+	}
+}
+
+func TestClusterSecretStoreReconciler_RejectsStartupProviderName(t *testing.T) {
+	scheme := newStoreTestScheme(t)
+	store := &jasmv1alpha1.ClusterSecretStore{
+		ObjectMeta: metav1.ObjectMeta{Name: "vault-kv"},
+		Spec: jasmv1alpha1.SecretStoreSpec{
+			Type:   "onepassword",
+			Config: &runtime.RawExtension{Raw: []byte(`{"host":"http://connect:8080","token":"connect-token"}`)},
+		},
+	}
+	c := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithStatusSubresource(&jasmv1alpha1.ClusterSecretStore{}).
+		WithObjects(store).
+		Build()
+
+	startup := &testProvider{name: "vault-kv"}
+	registry := provider.NewProviderRegistry()
+	registry.Register(startup)
+	r := &ClusterSecretStoreReconciler{Client: c, Scheme: scheme, ProviderRegistry: registry}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{
+		NamespacedName: types.NamespacedName{Name: "vault-kv"},
+	}); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if got := registry.Resolve("default", "vault-kv"); got != startup {
+		t.Errorf("Resolve() = %v, want the startup provider", got)
+	}
+	if names := registry.List(); len(names) != 1 || names[0] != "vault-kv" {
+		t.Errorf("List() = %v, want [vault-kv]", names)
+	}
+
+	var got jasmv1alpha1.ClusterSecretStore
+	if err := c.Get(context.Background(), types.NamespacedName{Name: "vault-kv"}, &got); err != nil {
+		t.Fatal(err)
+	}
+	condition := meta.FindStatusCondition(got.Status.Conditions, jasmv1alpha1.SecretStoreReadyCondition)
+	if condition == nil || condition.Status != metav1.ConditionFalse || !strings.Contains(condition.Message, "already used by a provider configured at startup") {
+		t.Errorf("Ready condition = %+v, want False for the name collision", condition)
+	}
+}
@@ -22,6 +22,9 @@ const (
 
 	// EventReasonSecretFetchFailed indicates failure to fetch secret from provider
 	EventReasonSecretFetchFailed = "SecretFetchFailed"
+
+	// EventReasonTemplateRenderFailed indicates failure to render a secret value template
+	EventReasonTemplateRenderFailed = "TemplateRenderFailed"
 )
 
 // EmitSecretSyncSuccess emits a Normal event when secret sync succeeds.
@@ -30,6 +33,13 @@ func EmitSecretSyncSuccess(recorder record.EventRecorder, pod *corev1.Pod, secre
 		"Successfully synchronized secret '%s' from %s (path: %s)", secretName, provider, path)
 }
 
+// EmitSecretSyncFailed emits a Warning event when the fetched data cannot be
+// written to the secret.
+func EmitSecretSyncFailed(recorder record.EventRecorder, pod *corev1.Pod, secretName string, err error) {
+	recorder.Eventf(pod, corev1.EventTypeWarning, EventReasonSecretSyncFailed,
+		"Failed to synchronize secret '%s': %v", secretName, err)
+}
+
 // EmitAnnotationInvalid emits a Warning event when annotation is invalid.
 func EmitAnnotationInvalid(recorder record.EventRecorder, pod *corev1.Pod, err error) {
 	recorder.Eventf(pod, corev1.EventTypeWarning, EventReasonAnnotationInvalid,
@@ -42,6 +52,12 @@ func EmitSecretFetchFailed(recorder record.EventRecorder, pod *corev1.Pod, provi
 		"Failed to fetch secret from %s (path: %s): %v", provider, path, err)
 }
 
+// EmitTemplateRenderFailed emits a Warning event when rendering a template fails.
+func EmitTemplateRenderFailed(recorder record.EventRecorder, pod *corev1.Pod, secretName string, err error) {
+	recorder.Eventf(pod, corev1.EventTypeWarning, EventReasonTemplateRenderFailed,
+		"Failed to render templates for secret '%s': %v", secretName, err)
+}
+
 // EmitProviderNotFound emits a Warning event when provider is not found in registry.
 func EmitProviderNotFound(recorder record.EventRecorder, pod *corev1.Pod, provider string) {
 	recorder.Eventf(pod, corev1.EventTypeWarning, EventReasonProviderUnsupported,
@@ -0,0 +1,205 @@
+// Package mapping turns the key-value pairs fetched from a provider into the
+// data of a Kubernetes secret, applying the annotation's key mapping and
+// flattening nested JSON values.
+package mapping
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FlattenSeparator joins the segments of flattened keys.
+const FlattenSeparator = "_"
+
+// Apply builds secret data from the fetched data.
+//
+// With flatten set, values holding a JSON object or array are expanded into
+// one key per leaf ({"db":{"primary":{"password":"x"}}} becomes
+// "db_primary_password"). Without keyMapping every key is returned; otherwise
+// only the mapped keys are, each target key taking the value of its source.
+// A source is a key of the (flattened) data or a path into nested JSON
+// values (see Lookup). Sources that cannot be resolved are returned in
+// missing.
+func Apply(data map[string][]byte, keyMapping map[string]string, flatten bool) (result map[string][]byte, missing []string) {
+	view := data
+	if flatten {
+		view = Flatten(data)
+	}
+
+	if len(keyMapping) == 0 {
+		result = make(map[string][]byte, len(view))
+		for key, value := range view {
+			result[key] = value
+		}
+		return result, nil
+	}
+
+	result = make(map[string][]byte, len(keyMapping))
+	for targetKey, source := range keyMapping {
+		if value, ok := view[source]; ok {
+			result[targetKey] = value
+			continue
+		}
+		if value, ok := Lookup(data, source); ok {
+			result[targetKey] = value
+			continue
+		}
+		missing = append(missing, source)
+	}
+	return result, missing
+}
+
+// Merge combines the data fetched from several sources into one map. Sources
+// are given in increasing order of precedence: a key present in several
+// sources takes the value of the last one.
+func Merge(sources ...map[string][]byte) map[string][]byte {
+	merged := make(map[string][]byte)
+	for _, data := range sources {
+		for key, value := range data {
+			merged[key] = value
+		}
+	}
+	return merged
+}
+
+// Flatten expands values holding a JSON object or array into one key per
+// leaf, joining nested keys and array indexes with FlattenSeparator. Other
+// values are kept as-is.
+func Flatten(data map[string][]byte) map[string][]byte {
+	flat := make(map[string][]byte, len(data))
+	for key, value := range data {
+		nested, ok := decodeNested(value)
+		if !ok {
+			flat[key] = value
+			continue
+		}
+		flattenValue(flat, key, nested)
+	}
+	return flat
+}
+
+// flattenValue adds the leaves of value to flat under prefix.
+func flattenValue(flat map[string][]byte, prefix string, value interface{}) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			flattenValue(flat, prefix+FlattenSeparator+key, child)
+		}
+	case []interface{}:
+		for i, child := range v {
+			flattenValue(flat, prefix+FlattenSeparator+strconv.Itoa(i), child)
+		}
+	default:
+		flat[prefix] = scalarBytes(v)
+	}
+}
+
+// Lookup resolves a path into nested JSON values. Paths are dotted
+// ("db.primary.password") or JSONPath-style ("$.db.primary.password",
+// "$.hosts[0]", "$['key.with.dots']"). The first segment selects a key of
+// data; the remaining segments descend into its JSON value. Objects and
+// arrays are returned as JSON.
+func Lookup(data map[string][]byte, path string) ([]byte, bool) {
+	segments, err := parsePath(path)
+	if err != nil || len(segments) == 0 {
+		return nil, false
+	}
+
+	value, ok := data[segments[0]]
+	if !ok {
+		return nil, false
+	}
+	if len(segments) == 1 {
+		return value, true
+	}
+
+	current, ok := decodeNested(value)
+	if !ok {
+		return nil, false
+	}
+	for _, segment := range segments[1:] {
+		switch v := current.(type) {
+		case map[string]interface{}:
+			if current, ok = v[segment]; !ok {
+				return nil, false
+			}
+		case []interface{}:
+			index, err := strconv.Atoi(segment)
+			if err != nil || index < 0 || index >= len(v) {
+				return nil, false
+			}
+			current = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return scalarBytes(current), true
+}
+
+// parsePath splits a dotted or JSONPath-style path into its segments.
+func parsePath(path string) ([]string, error) {
+	path = strings.TrimPrefix(path, "$")
+	var segments []string
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated [ in path")
+			}
+			segment := path[1:end]
+			if len(segment) >= 2 && (segment[0] == '\'' || segment[0] == '"') && segment[len(segment)-1] == segment[0] {
+				segment = segment[1 : len(segment)-1]
+			}
+			segments = append(segments, segment)
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end < 0 {
+				end = len(path)
+			}
+			segments = append(segments, path[:end])
+			path = path[end:]
+		}
+	}
+	return segments, nil
+}
+
+// decodeNested decodes a value holding a JSON object or array.
+func decodeNested(value []byte) (interface{}, bool) {
+	trimmed := bytes.TrimSpace(value)
+	if len(trimmed) == 0 || (trimmed[0] != '{' && trimmed[0] != '[') {
+		return nil, false
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(trimmed))
+	decoder.UseNumber()
+	var nested interface{}
+	if err := decoder.Decode(&nested); err != nil {
+		return nil, false
+	}
+	return nested, true
+}
+
+// scalarBytes formats a decoded JSON value the way providers format
+// top-level values: strings as-is, null as empty, objects and arrays as JSON.
+func scalarBytes(value interface{}) []byte {
+	switch v := value.(type) {
+	case string:
+		return []byte(v)
+	case json.Number:
+		return []byte(v.String())
+	case bool:
+		return []byte(strconv.FormatBool(v))
+	case nil:
+		return []byte{}
+	default:
+		encoded, _ := json.Marshal(v)
+		return encoded
+	}
+}
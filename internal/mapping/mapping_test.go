@@ -0,0 +1,150 @@
+package mapping
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testData() map[string][]byte {
+	return map[string][]byte{
+		"username":   []byte("admin"),
+		"db":         []byte(`{"primary":{"password":"s3cret","port":5432},"replicas":["r1","r2"]}`),
+		"dotted.key": []byte("literal"),
+		"tls":        []byte(`{"ca.crt":"CA"}`),
+		"payload":    []byte("{not json"),
+	}
+}
+
+func TestLookup(t *testing.T) {
+	tests := []struct {
+		path  string
+		want  string
+		found bool
+	}{
+		{path: "username", want: "admin", found: true},
+		{path: "db.primary.password", want: "s3cret", found: true},
+		{path: "$.db.primary.password", want: "s3cret", found: true},
+		{path: "db.primary.port", want: "5432", found: true},
+		{path: "db.primary", want: `{"password":"s3cret","port":5432}`, found: true},
+		{path: "$.db.replicas[1]", want: "r2", found: true},
+		{path: "db.replicas.0", want: "r1", found: true},
+		{path: "$.tls['ca.crt']", want: "CA", found: true},
+		{path: "db.replicas[5]", found: false},
+		{path: "db.primary.user", found: false},
+		{path: "username.first", found: false},
+		{path: "payload.field", found: false},
+		{path: "db[", found: false},
+		{path: "$", found: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, found := Lookup(testData(), tt.path)
+			if found != tt.found {
+				t.Fatalf("Lookup(%q) found = %v, want %v", tt.path, found, tt.found)
+			}
+			if found && string(got) != tt.want {
+				t.Errorf("Lookup(%q) = %q, want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlatten(t *testing.T) {
+	got := Flatten(testData())
+
+	want := map[string]string{
+		"username":            "admin",
+		"db_primary_password": "s3cret",
+		"db_primary_port":     "5432",
+		"db_replicas_0":       "r1",
+		"db_replicas_1":       "r2",
+		"dotted.key":          "literal",
+		"tls_ca.crt":          "CA",
+		"payload":             "{not json",
+	}
+	if !reflect.DeepEqual(stringData(got), want) {
+		t.Errorf("Flatten() = %v, want %v", stringData(got), want)
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name        string
+		keyMapping  map[string]string
+		flatten     bool
+		want        map[string]string
+		wantMissing []string
+	}{
+		{
+			name: "no mapping copies all keys",
+			want: map[string]string{
+				"username":   "admin",
+				"db":         `{"primary":{"password":"s3cret","port":5432},"replicas":["r1","r2"]}`,
+				"dotted.key": "literal",
+				"tls":        `{"ca.crt":"CA"}`,
+				"payload":    "{not json",
+			},
+		},
+		{
+			name: "literal keys win over paths",
+			keyMapping: map[string]string{
+				"LITERAL":  "dotted.key",
+				"PASSWORD": "db.primary.password",
+				"MISSING":  "db.primary.user",
+			},
+			want: map[string]string{
+				"LITERAL":  "literal",
+				"PASSWORD": "s3cret",
+			},
+			wantMissing: []string{"db.primary.user"},
+		},
+		{
+			name: "flattened keys and paths",
+			keyMapping: map[string]string{
+				"PASSWORD": "db_primary_password",
+				"REPLICA":  "$.db.replicas[0]",
+			},
+			flatten: true,
+			want: map[string]string{
+				"PASSWORD": "s3cret",
+				"REPLICA":  "r1",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, missing := Apply(testData(), tt.keyMapping, tt.flatten)
+			if !reflect.DeepEqual(stringData(got), tt.want) {
+				t.Errorf("Apply() = %v, want %v", stringData(got), tt.want)
+			}
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("Apply() missing = %v, want %v", missing, tt.wantMissing)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	common := map[string][]byte{"host": []byte("db.internal"), "password": []byte("shared")}
+	app := map[string][]byte{"password": []byte("app-only"), "token": []byte("tok")}
+
+	got := Merge(common, app)
+
+	want := map[string]string{"host": "db.internal", "password": "app-only", "token": "tok"}
+	if !reflect.DeepEqual(stringData(got), want) {
+		t.Errorf("Merge() = %v, want %v", stringData(got), want)
+	}
+	if string(common["password"]) != "shared" {
+		t.Error("Merge() modified its input")
+	}
+}
+
+func stringData(data map[string][]byte) map[string]string {
+	out := make(map[string]string, len(data))
+	for key, value := range data {
+		out[key] = string(value)
+	}
+	return out
+}
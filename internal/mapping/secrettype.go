@@ -0,0 +1,130 @@
+package mapping
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// Keys the dockerconfigjson helper builds a registry config from.
+const (
+	DockerRegistryKey = "registry"
+	DockerUsernameKey = "username"
+	DockerPasswordKey = "password"
+	DockerEmailKey    = "email"
+)
+
+// secretTypes maps the short secret type names accepted in annotations to
+// Kubernetes secret types.
+var secretTypes = map[string]corev1.SecretType{
+	"opaque":           corev1.SecretTypeOpaque,
+	"tls":              corev1.SecretTypeTLS,
+	"dockerconfigjson": corev1.SecretTypeDockerConfigJson,
+	"basic-auth":       corev1.SecretTypeBasicAuth,
+	"ssh-auth":         corev1.SecretTypeSSHAuth,
+}
+
+// ParseSecretType returns the Kubernetes secret type named by name, either by
+// its short name ("tls", "dockerconfigjson", "basic-auth", "ssh-auth",
+// "opaque") or its full type ("kubernetes.io/tls"). Empty means Opaque.
+func ParseSecretType(name string) (corev1.SecretType, error) {
+	if name == "" {
+		return corev1.SecretTypeOpaque, nil
+	}
+	if secretType, ok := secretTypes[strings.ToLower(name)]; ok {
+		return secretType, nil
+	}
+	for _, secretType := range secretTypes {
+		if string(secretType) == name {
+			return secretType, nil
+		}
+	}
+	return "", fmt.Errorf("unsupported secret type %q", name)
+}
+
+// PrepareTypedData checks that data holds the keys Kubernetes requires for
+// secretType. For dockerconfigjson secrets without a .dockerconfigjson key,
+// the registry config is built from the registry, username, password and
+// optional email keys, which it replaces.
+func PrepareTypedData(secretType corev1.SecretType, data map[string][]byte) (map[string][]byte, error) {
+	switch secretType {
+	case corev1.SecretTypeTLS:
+		if err := requireKeys(secretType, data, corev1.TLSCertKey, corev1.TLSPrivateKeyKey); err != nil {
+			return nil, err
+		}
+
+	case corev1.SecretTypeDockerConfigJson:
+		if _, ok := data[corev1.DockerConfigJsonKey]; !ok {
+			return buildDockerConfigJSON(data)
+		}
+		if !json.Valid(data[corev1.DockerConfigJsonKey]) {
+			return nil, fmt.Errorf("%s secrets need valid JSON in key %s", secretType, corev1.DockerConfigJsonKey)
+		}
+
+	case corev1.SecretTypeBasicAuth:
+		_, hasUsername := data[corev1.BasicAuthUsernameKey]
+		_, hasPassword := data[corev1.BasicAuthPasswordKey]
+		if !hasUsername && !hasPassword {
+			return nil, fmt.Errorf("%s secrets need key %s or %s", secretType, corev1.BasicAuthUsernameKey, corev1.BasicAuthPasswordKey)
+		}
+
+	case corev1.SecretTypeSSHAuth:
+		if err := requireKeys(secretType, data, corev1.SSHAuthPrivateKey); err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+// buildDockerConfigJSON replaces the registry credential keys of data with a
+// .dockerconfigjson registry config.
+func buildDockerConfigJSON(data map[string][]byte) (map[string][]byte, error) {
+	if err := requireKeys(corev1.SecretTypeDockerConfigJson, data, DockerRegistryKey, DockerUsernameKey, DockerPasswordKey); err != nil {
+		return nil, fmt.Errorf("%w (or key %s)", err, corev1.DockerConfigJsonKey)
+	}
+
+	username := string(data[DockerUsernameKey])
+	password := string(data[DockerPasswordKey])
+	entry := map[string]string{
+		"username": username,
+		"password": password,
+		"auth":     base64.StdEncoding.EncodeToString([]byte(username + ":" + password)),
+	}
+	if email, ok := data[DockerEmailKey]; ok {
+		entry["email"] = string(email)
+	}
+	config, err := json.Marshal(map[string]interface{}{
+		"auths": map[string]interface{}{string(data[DockerRegistryKey]): entry},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode docker config: %w", err)
+	}
+
+	result := make(map[string][]byte, len(data))
+	for key, value := range data {
+		switch key {
+		case DockerRegistryKey, DockerUsernameKey, DockerPasswordKey, DockerEmailKey:
+		default:
+			result[key] = value
+		}
+	}
+	result[corev1.DockerConfigJsonKey] = config
+	return result, nil
+}
+
+// requireKeys returns an error naming the keys data lacks.
+func requireKeys(secretType corev1.SecretType, data map[string][]byte, keys ...string) error {
+	var missing []string
+	for _, key := range keys {
+		if _, ok := data[key]; !ok {
+			missing = append(missing, key)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%s secrets need keys %s, missing %s", secretType, strings.Join(keys, ", "), strings.Join(missing, ", "))
+	}
+	return nil
+}
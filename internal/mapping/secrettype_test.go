@@ -0,0 +1,110 @@
+package mapping
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestParseSecretType(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    corev1.SecretType
+		wantErr bool
+	}{
+		{name: "", want: corev1.SecretTypeOpaque},
+		{name: "tls", want: corev1.SecretTypeTLS},
+		{name: "TLS", want: corev1.SecretTypeTLS},
+		{name: "dockerconfigjson", want: corev1.SecretTypeDockerConfigJson},
+		{name: "basic-auth", want: corev1.SecretTypeBasicAuth},
+		{name: "ssh-auth", want: corev1.SecretTypeSSHAuth},
+		{name: "kubernetes.io/tls", want: corev1.SecretTypeTLS},
+		{name: "Opaque", want: corev1.SecretTypeOpaque},
+		{name: "kubernetes.io/service-account-token", wantErr: true},
+		{name: "certificate", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseSecretType(tt.name)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSecretType(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseSecretType(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrepareTypedData(t *testing.T) {
+	tests := []struct {
+		name       string
+		secretType corev1.SecretType
+		data       map[string]string
+		wantErr    string
+	}{
+		{name: "opaque", secretType: corev1.SecretTypeOpaque, data: map[string]string{}},
+		{name: "tls", secretType: corev1.SecretTypeTLS, data: map[string]string{"tls.crt": "CRT", "tls.key": "KEY"}},
+		{name: "tls without key", secretType: corev1.SecretTypeTLS, data: map[string]string{"tls.crt": "CRT"}, wantErr: "missing tls.key"},
+		{name: "basic-auth password only", secretType: corev1.SecretTypeBasicAuth, data: map[string]string{"password": "p"}},
+		{name: "basic-auth empty", secretType: corev1.SecretTypeBasicAuth, data: map[string]string{"token": "t"}, wantErr: "need key username or password"},
+		{name: "ssh-auth", secretType: corev1.SecretTypeSSHAuth, data: map[string]string{"ssh-privatekey": "KEY"}},
+		{name: "ssh-auth without key", secretType: corev1.SecretTypeSSHAuth, data: map[string]string{}, wantErr: "missing ssh-privatekey"},
+		{name: "dockerconfigjson", secretType: corev1.SecretTypeDockerConfigJson, data: map[string]string{".dockerconfigjson": `{"auths":{}}`}},
+		{name: "dockerconfigjson invalid", secretType: corev1.SecretTypeDockerConfigJson, data: map[string]string{".dockerconfigjson": "{"}, wantErr: "need valid JSON"},
+		{name: "dockerconfigjson without credentials", secretType: corev1.SecretTypeDockerConfigJson, data: map[string]string{"registry": "ghcr.io"}, wantErr: "missing username, password (or key .dockerconfigjson)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := make(map[string][]byte, len(tt.data))
+			for key, value := range tt.data {
+				data[key] = []byte(value)
+			}
+			_, err := PrepareTypedData(tt.secretType, data)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("PrepareTypedData() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("PrepareTypedData() error = %v, want error containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPrepareTypedData_DockerConfigJSON(t *testing.T) {
+	got, err := PrepareTypedData(corev1.SecretTypeDockerConfigJson, map[string][]byte{
+		"registry": []byte("ghcr.io"),
+		"username": []byte("bot"),
+		"password": []byte("pat"),
+		"note":     []byte("kept"),
+	})
+	if err != nil {
+		t.Fatalf("PrepareTypedData() error = %v", err)
+	}
+
+	if len(got) != 2 || string(got["note"]) != "kept" {
+		t.Errorf("Expected .dockerconfigjson and note only, got %v", stringData(got))
+	}
+
+	var config struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(got[corev1.DockerConfigJsonKey], &config); err != nil {
+		t.Fatalf("Invalid docker config: %v", err)
+	}
+	entry := config.Auths["ghcr.io"]
+	if entry.Username != "bot" || entry.Password != "pat" || entry.Auth != "Ym90OnBhdA==" {
+		t.Errorf("Unexpected registry entry: %+v", entry)
+	}
+}
@@ -0,0 +1,87 @@
+package mapping
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// templateFuncs are the functions available to templates in addition to the
+// text/template builtins. They only transform values; none reads files, the
+// environment or the network.
+var templateFuncs = template.FuncMap{
+	"b64enc": func(value string) string {
+		return base64.StdEncoding.EncodeToString([]byte(value))
+	},
+	"b64dec": func(value string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to decode base64: %w", err)
+		}
+		return string(decoded), nil
+	},
+	"toJSON": func(value interface{}) (string, error) {
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", fmt.Errorf("failed to encode JSON: %w", err)
+		}
+		return string(encoded), nil
+	},
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"quote": strconv.Quote,
+}
+
+// ParseTemplate parses a secret value template. Referencing a key the
+// fetched data does not hold is an error when the template is rendered.
+func ParseTemplate(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+}
+
+// Render renders each template against the fetched data and returns the
+// results keyed like templates. Keys of data are template fields
+// ({{ .username }}); values holding a JSON object or array can be walked
+// into ({{ .db.primary.password }}).
+func Render(data map[string][]byte, templates map[string]string) (map[string][]byte, error) {
+	values := templateData(data)
+
+	keys := make([]string, 0, len(templates))
+	for key := range templates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rendered := make(map[string][]byte, len(templates))
+	for _, key := range keys {
+		tmpl, err := ParseTemplate(key, templates[key])
+		if err != nil {
+			return nil, fmt.Errorf("invalid template %s: %w", key, err)
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, values); err != nil {
+			return nil, fmt.Errorf("failed to render template %s: %w", key, err)
+		}
+		rendered[key] = out.Bytes()
+	}
+	return rendered, nil
+}
+
+// templateData converts fetched data into template values: nested JSON
+// values are decoded, anything else is a string.
+func templateData(data map[string][]byte) map[string]interface{} {
+	values := make(map[string]interface{}, len(data))
+	for key, value := range data {
+		if nested, ok := decodeNested(value); ok {
+			values[key] = nested
+			continue
+		}
+		values[key] = string(value)
+	}
+	return values
+}
@@ -4,28 +4,106 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// AWSConfig holds optional overrides for the AWS providers.
+// Unset fields fall back to the default AWS configuration chain.
+type AWSConfig struct {
+	// Region overrides the region from the environment.
+	Region string `yaml:"region"`
+	// RoleARN is an IAM role assumed through STS on top of the ambient credentials.
+	RoleARN string `yaml:"roleArn"`
+	// ExternalID is passed to STS when assuming RoleARN or a namespace role (optional).
+	ExternalID string `yaml:"externalId"`
+	// NamespaceRoles maps namespaces to IAM roles assumed when fetching
+	// secrets for pods in them (optional).
+	NamespaceRoles map[string]string `yaml:"namespaceRoles"`
+	// NamespaceRoleAnnotation also reads the role of a namespace from its
+	// AWSRoleAnnotation. NamespaceRoles takes precedence. Requires Client.
+	NamespaceRoleAnnotation bool `yaml:"namespaceRoleAnnotation"`
+	// RequireNamespaceRole rejects requests from namespaces without a role
+	// instead of serving them with the controller's own identity.
+	RequireNamespaceRole bool `yaml:"requireNamespaceRole"`
+	// Client reads Namespaces when NamespaceRoleAnnotation is set.
+	Client client.Reader `yaml:"-"`
+}
+
+// loadAWSConfig loads the default AWS configuration and applies the overrides
+// in cfg. Assumed-role credentials are cached and refreshed before they expire.
+func loadAWSConfig(ctx context.Context, cfg AWSConfig) (aws.Config, error) {
+	var optFns []func(*config.LoadOptions) error
+	if cfg.Region != "" {
+		optFns = append(optFns, config.WithRegion(cfg.Region))
+	}
+
+	awsCfg, err := config.LoadDefaultConfig(ctx, optFns...)
+	if err != nil {
+		return aws.Config{}, fmt.Errorf("failed to load AWS config: %w", err)
+	}
+
+	if cfg.RoleARN != "" {
+		awsCfg.Credentials = assumeRoleCredentials(awsCfg, cfg.RoleARN, cfg.ExternalID)
+	}
+
+	return awsCfg, nil
+}
+
+// assumeRoleCredentials returns credentials for roleARN, assumed with the
+// credentials of base. They are cached and refreshed before they expire.
+func assumeRoleCredentials(base aws.Config, roleARN, externalID string) aws.CredentialsProvider {
+	assumeRole := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(base), roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = "jasm"
+		if externalID != "" {
+			o.ExternalID = aws.String(externalID)
+		}
+	})
+	return aws.NewCredentialsCache(assumeRole)
+}
+
+// secretsManagerAPI is the subset of the Secrets Manager client used by
+// AWSSecretsManagerProvider.
+type secretsManagerAPI interface {
+	GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error)
+}
+
 // AWSSecretsManagerProvider implements SecretProvider for AWS Secrets Manager.
+//
+// The path is a secret name or ARN. Secrets are read from the region of the
+// ARN, the region requested in FetchOptions, or the configured region, in
+// that order; clients for other regions are created on first use.
+//
+// When per-namespace roles are configured, each request is served with the
+// role of the requesting pod's namespace (see AWSConfig.NamespaceRoles).
 type AWSSecretsManagerProvider struct {
-	client *secretsmanager.Client
+	clients *awsClients[secretsManagerAPI]
 }
 
 // NewAWSSecretsManagerProvider creates a new AWS Secrets Manager provider.
 // It uses the default AWS configuration which respects AWS_PROFILE environment variable.
 func NewAWSSecretsManagerProvider(ctx context.Context) (*AWSSecretsManagerProvider, error) {
-	cfg, err := config.LoadDefaultConfig(ctx)
+	return NewAWSSecretsManagerProviderWithConfig(ctx, AWSConfig{})
+}
+
+// NewAWSSecretsManagerProviderWithConfig creates a new AWS Secrets Manager
+// provider using the region and role settings in cfg.
+func NewAWSSecretsManagerProviderWithConfig(ctx context.Context, cfg AWSConfig) (*AWSSecretsManagerProvider, error) {
+	clients, err := newAWSClients(ctx, cfg, func(awsCfg aws.Config) secretsManagerAPI {
+		return secretsmanager.NewFromConfig(awsCfg)
+	})
 	if err != nil {
-		return nil, fmt.Errorf("failed to load AWS config: %w", err)
+		return nil, err
 	}
 
-	return &AWSSecretsManagerProvider{
-		client: secretsmanager.NewFromConfig(cfg),
-	}, nil
+	return &AWSSecretsManagerProvider{clients: clients}, nil
 }
 
 // Name returns the provider identifier.
@@ -33,46 +111,91 @@ func (p *AWSSecretsManagerProvider) Name() string {
 	return "aws-secretsmanager"
 }
 
+// SupportedFetchOptions returns the FetchOptions settings read by
+// FetchSecretValue.
+func (p *AWSSecretsManagerProvider) SupportedFetchOptions() []string {
+	return []string{"region", "version", "versionStage", "format", "textKey"}
+}
+
 // FetchSecret retrieves a secret from AWS Secrets Manager.
 // The secret value is expected to be a JSON object with string key-value pairs.
+// Binary secrets are returned as a single key (see FetchSecretValue).
 func (p *AWSSecretsManagerProvider) FetchSecret(ctx context.Context, path string) (map[string]string, error) {
+	value, err := p.FetchSecretValue(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return stringSecretData(value.Data), nil
+}
+
+// FetchSecretValue retrieves a secret from AWS Secrets Manager along with the
+// ID of the version that was read. The version and staging label requested
+// in FetchOptions are passed through; without them AWSCURRENT is read.
+//
+// A SecretString must hold a JSON object, whose keys become the secret's
+// keys, unless FetchOptions.Format is FormatText: the string is then returned
+// as-is under FetchOptions.TextKey. A SecretBinary is returned as-is under the
+// secret's name (the last path segment, e.g. "keystore" for "prod/app/keystore").
+func (p *AWSSecretsManagerProvider) FetchSecretValue(ctx context.Context, path string) (*SecretValue, error) {
+	opts := FetchOptionsFrom(ctx)
+	region, err := awsRegionFor(path, opts.Region)
+	if err != nil {
+		return nil, err
+	}
+	client, err := p.clients.get(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
 	input := &secretsmanager.GetSecretValueInput{
 		SecretId: aws.String(path),
 	}
+	if opts.Version != "" {
+		input.VersionId = aws.String(opts.Version)
+	}
+	if opts.VersionStage != "" {
+		input.VersionStage = aws.String(opts.VersionStage)
+	}
 
-	result, err := p.client.GetSecretValue(ctx, input)
+	result, err := client.GetSecretValue(ctx, input)
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch secret from AWS Secrets Manager: %w", err)
 	}
 
-	if result.SecretString == nil {
-		return nil, fmt.Errorf("secret %s does not contain a string value", path)
+	value := &SecretValue{Version: aws.ToString(result.VersionId)}
+	switch {
+	case result.SecretString != nil && opts.Format == FormatText:
+		textKey := opts.TextKey
+		if textKey == "" {
+			textKey = awsSecretBaseName(path)
+		}
+		value.Data = map[string][]byte{textKey: []byte(*result.SecretString)}
+	case result.SecretString != nil:
+		// Parse JSON secret
+		var rawData map[string]interface{}
+		if err := json.Unmarshal([]byte(*result.SecretString), &rawData); err != nil {
+			return nil, fmt.Errorf("failed to parse secret JSON (use format: text for plain-text secrets): %w", err)
+		}
+		value.Data = bytesSecretData(stringifySecretData(rawData))
+	case result.SecretBinary != nil:
+		value.Data = map[string][]byte{awsSecretBaseName(path): result.SecretBinary}
+	default:
+		return nil, fmt.Errorf("secret %s has neither a string nor a binary value", path)
 	}
 
-	// Parse JSON secret
-	var rawData map[string]interface{}
-	if err := json.Unmarshal([]byte(*result.SecretString), &rawData); err != nil {
-		return nil, fmt.Errorf("failed to parse secret JSON: %w", err)
-	}
+	return value, nil
+}
 
-	// Convert all values to strings
-	secretData := make(map[string]string, len(rawData))
-	for key, value := range rawData {
-		switch v := value.(type) {
-		case string:
-			secretData[key] = v
-		case float64:
-			secretData[key] = fmt.Sprintf("%v", v)
-		case bool:
-			secretData[key] = fmt.Sprintf("%v", v)
-		case nil:
-			secretData[key] = ""
-		default:
-			// For complex types (objects, arrays), convert to JSON
-			jsonBytes, _ := json.Marshal(v)
-			secretData[key] = string(jsonBytes)
+// awsSecretBaseName returns the last segment of a secret name or ARN. The
+// random suffix Secrets Manager appends to ARNs is removed.
+func awsSecretBaseName(path string) string {
+	if arn.IsARN(path) {
+		if parsed, err := arn.Parse(path); err == nil {
+			path = strings.TrimPrefix(parsed.Resource, "secret:")
+			if i := strings.LastIndex(path, "-"); i >= 0 && len(path)-i == 7 {
+				path = path[:i]
+			}
 		}
 	}
-
-	return secretData, nil
+	return path[strings.LastIndex(path, "/")+1:]
 }
@@ -0,0 +1,175 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/arn"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// AWSRoleAnnotation is the Namespace annotation naming the IAM role assumed
+// when fetching AWS secrets for pods in that namespace. It is only honored
+// when AWSConfig.NamespaceRoleAnnotation is set, so only grant users who may
+// pick a role permission to annotate Namespaces.
+const AWSRoleAnnotation = "jasm.codnod.io/aws-role-arn"
+
+// LoadAWSNamespaceRoles reads a YAML or JSON file mapping namespaces to the
+// IAM role ARNs assumed for them.
+//
+//	team-a: arn:aws:iam::111111111111:role/team-a-secrets
+//	team-b: arn:aws:iam::222222222222:role/team-b-secrets
+func LoadAWSNamespaceRoles(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read AWS namespace roles %s: %w", path, err)
+	}
+
+	var roles map[string]string
+	if err := decodeStrict(data, &roles); err != nil {
+		return nil, fmt.Errorf("failed to parse AWS namespace roles %s: %w", path, err)
+	}
+	return roles, nil
+}
+
+// awsClients lazily creates AWS service clients per region and per IAM role
+// assumed on behalf of the requesting namespace. Assumed-role credentials are
+// cached per role, shared across regions, and refreshed before they expire.
+type awsClients[T any] struct {
+	base           aws.Config
+	newClient      func(aws.Config) T
+	externalID     string
+	namespaceRoles map[string]string
+	requireRole    bool
+	reader         client.Reader
+
+	mu          sync.Mutex
+	clients     map[awsClientKey]T
+	credentials map[string]aws.CredentialsProvider
+}
+
+// awsClientKey identifies a cached client. An empty roleARN is the base
+// identity; an empty region is the base region.
+type awsClientKey struct {
+	roleARN string
+	region  string
+}
+
+// awsRegionPattern matches AWS region names such as "eu-west-1" or
+// "us-gov-west-1". Regions end up in endpoint host names, so anything else
+// is rejected.
+var awsRegionPattern = regexp.MustCompile(`^[a-z]{2}(-[a-z]+)+-[0-9]+$`)
+
+// newAWSClients loads the base AWS configuration for cfg and returns a
+// client cache creating clients with newClient.
+func newAWSClients[T any](ctx context.Context, cfg AWSConfig, newClient func(aws.Config) T) (*awsClients[T], error) {
+	if cfg.NamespaceRoleAnnotation && cfg.Client == nil {
+		return nil, fmt.Errorf("a kubernetes client is required to read namespace role annotations")
+	}
+
+	base, err := loadAWSConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	clients := &awsClients[T]{
+		base:           base,
+		newClient:      newClient,
+		externalID:     cfg.ExternalID,
+		namespaceRoles: cfg.NamespaceRoles,
+		requireRole:    cfg.RequireNamespaceRole,
+		clients:        make(map[awsClientKey]T),
+		credentials:    make(map[string]aws.CredentialsProvider),
+	}
+	if cfg.NamespaceRoleAnnotation {
+		clients.reader = cfg.Client
+	}
+	return clients, nil
+}
+
+// get returns the client for region (or the base region when empty) and
+// the namespace carried by ctx.
+func (c *awsClients[T]) get(ctx context.Context, region string) (T, error) {
+	var zero T
+	if region != "" && !awsRegionPattern.MatchString(region) {
+		return zero, fmt.Errorf("invalid AWS region %q", region)
+	}
+	if region == c.base.Region {
+		region = ""
+	}
+
+	roleARN, err := c.roleFor(ctx, RequestNamespace(ctx))
+	if err != nil {
+		return zero, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := awsClientKey{roleARN: roleARN, region: region}
+	if cached, ok := c.clients[key]; ok {
+		return cached, nil
+	}
+
+	awsCfg := c.base.Copy()
+	if region != "" {
+		awsCfg.Region = region
+	}
+	if roleARN != "" {
+		credentials, ok := c.credentials[roleARN]
+		if !ok {
+			credentials = assumeRoleCredentials(c.base, roleARN, c.externalID)
+			c.credentials[roleARN] = credentials
+		}
+		awsCfg.Credentials = credentials
+	}
+	created := c.newClient(awsCfg)
+	c.clients[key] = created
+	return created, nil
+}
+
+// awsRegionFor returns the region to fetch path from: the region of path
+// when it is an ARN, else the requested region (empty for the default).
+func awsRegionFor(path, requested string) (string, error) {
+	if !arn.IsARN(path) {
+		return requested, nil
+	}
+	parsed, err := arn.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid ARN %q: %w", path, err)
+	}
+	if requested != "" && requested != parsed.Region {
+		return "", fmt.Errorf("region %s does not match the region of ARN %s", requested, path)
+	}
+	return parsed.Region, nil
+}
+
+// roleFor returns the IAM role to assume for namespace, or "" to use the
+// base identity.
+func (c *awsClients[T]) roleFor(ctx context.Context, namespace string) (string, error) {
+	if namespace != "" {
+		if roleARN := c.namespaceRoles[namespace]; roleARN != "" {
+			return roleARN, nil
+		}
+
+		if c.reader != nil {
+			var ns corev1.Namespace
+			if err := c.reader.Get(ctx, client.ObjectKey{Name: namespace}, &ns); err != nil {
+				return "", fmt.Errorf("failed to read namespace %s: %w", namespace, err)
+			}
+			if roleARN := ns.Annotations[AWSRoleAnnotation]; roleARN != "" {
+				return roleARN, nil
+			}
+		}
+	}
+
+	if c.requireRole {
+		return "", fmt.Errorf("no AWS role is configured for namespace %q", namespace)
+	}
+	return "", nil
+}
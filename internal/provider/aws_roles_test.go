@@ -0,0 +1,168 @@
+package provider
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// staticAWSClients returns a client cache serving c for the base identity.
+func staticAWSClients[T any](c T) *awsClients[T] {
+	return &awsClients[T]{clients: map[awsClientKey]T{{}: c}}
+}
+
+func TestAWSClients_NamespaceRoles(t *testing.T) {
+	reader := fake.NewClientBuilder().WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+			Name:        "team-b",
+			Annotations: map[string]string{AWSRoleAnnotation: "arn:aws:iam::222222222222:role/team-b"},
+		}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "team-c"}},
+	).Build()
+
+	clients, err := newAWSClients(context.Background(), AWSConfig{
+		Region:                  "eu-west-1",
+		NamespaceRoles:          map[string]string{"team-a": "arn:aws:iam::111111111111:role/team-a"},
+		NamespaceRoleAnnotation: true,
+		Client:                  reader,
+	}, func(cfg aws.Config) *aws.Config { return &cfg })
+	if err != nil {
+		t.Fatalf("newAWSClients() error = %v", err)
+	}
+
+	get := func(namespace string) *aws.Config {
+		t.Helper()
+		cfg, err := clients.get(WithRequestNamespace(context.Background(), namespace), "")
+		if err != nil {
+			t.Fatalf("get(%q) error = %v", namespace, err)
+		}
+		return cfg
+	}
+
+	teamA := get("team-a")
+	if get("team-a") != teamA {
+		t.Error("clients should be cached per role")
+	}
+	teamB := get("team-b")
+	teamC := get("team-c")
+	if teamA == teamB || teamA == teamC || teamB == teamC {
+		t.Error("namespaces with different roles should get different clients")
+	}
+	if _, ok := teamA.Credentials.(*aws.CredentialsCache); !ok {
+		t.Errorf("team-a credentials = %T, want assumed-role *aws.CredentialsCache", teamA.Credentials)
+	}
+	if teamC.Credentials != clients.base.Credentials {
+		t.Error("namespaces without a role should use the base identity")
+	}
+	if teamB.Region != "eu-west-1" {
+		t.Errorf("region = %q, want eu-west-1", teamB.Region)
+	}
+	if len(clients.clients) != 3 {
+		t.Errorf("cached clients = %d, want 3", len(clients.clients))
+	}
+
+	if _, err := clients.get(WithRequestNamespace(context.Background(), "missing"), ""); err == nil {
+		t.Error("get() expected error for a namespace that cannot be read")
+	}
+}
+
+func TestAWSClients_RequireNamespaceRole(t *testing.T) {
+	clients, err := newAWSClients(context.Background(), AWSConfig{
+		Region:               "eu-west-1",
+		NamespaceRoles:       map[string]string{"team-a": "arn:aws:iam::111111111111:role/team-a"},
+		RequireNamespaceRole: true,
+	}, func(cfg aws.Config) *aws.Config { return &cfg })
+	if err != nil {
+		t.Fatalf("newAWSClients() error = %v", err)
+	}
+
+	if _, err := clients.get(WithRequestNamespace(context.Background(), "team-a"), ""); err != nil {
+		t.Errorf("get(team-a) error = %v", err)
+	}
+	if _, err := clients.get(WithRequestNamespace(context.Background(), "team-b"), ""); err == nil {
+		t.Error("get(team-b) expected error without a role")
+	}
+}
+
+func TestLoadAWSNamespaceRoles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "roles.yaml")
+	writeTestFile(t, path, "team-a: arn:aws:iam::111111111111:role/team-a\n")
+
+	roles, err := LoadAWSNamespaceRoles(path)
+	if err != nil {
+		t.Fatalf("LoadAWSNamespaceRoles() error = %v", err)
+	}
+	if roles["team-a"] != "arn:aws:iam::111111111111:role/team-a" {
+		t.Errorf("roles = %v", roles)
+	}
+}
+
+func TestAWSClients_Regions(t *testing.T) {
+	clients, err := newAWSClients(context.Background(), AWSConfig{
+		Region:         "eu-west-1",
+		NamespaceRoles: map[string]string{"team-a": "arn:aws:iam::111111111111:role/team-a"},
+	}, func(cfg aws.Config) *aws.Config { return &cfg })
+	if err != nil {
+		t.Fatalf("newAWSClients() error = %v", err)
+	}
+	ctx := WithRequestNamespace(context.Background(), "team-a")
+
+	defaultRegion, err := clients.get(ctx, "")
+	if err != nil {
+		t.Fatalf("get() error = %v", err)
+	}
+	sameRegion, _ := clients.get(ctx, "eu-west-1")
+	if sameRegion != defaultRegion {
+		t.Error("the configured region should share the default client")
+	}
+
+	otherRegion, err := clients.get(ctx, "us-east-1")
+	if err != nil {
+		t.Fatalf("get(us-east-1) error = %v", err)
+	}
+	if otherRegion.Region != "us-east-1" {
+		t.Errorf("region = %q, want us-east-1", otherRegion.Region)
+	}
+	if otherRegion.Credentials != defaultRegion.Credentials {
+		t.Error("assumed-role credentials should be shared across regions")
+	}
+
+	if _, err := clients.get(ctx, "evil.example.com/"); err == nil {
+		t.Error("get() expected error for an invalid region")
+	}
+}
+
+func TestAWSRegionFor(t *testing.T) {
+	tests := []struct {
+		name      string
+		path      string
+		requested string
+		want      string
+		wantErr   bool
+	}{
+		{name: "Name uses default", path: "prod/db"},
+		{name: "Name uses requested", path: "prod/db", requested: "us-east-1", want: "us-east-1"},
+		{name: "ARN region", path: "arn:aws:secretsmanager:ap-south-1:123456789012:secret:prod/db-AbCdEf", want: "ap-south-1"},
+		{name: "SSM ARN region", path: "arn:aws:ssm:eu-central-1:123456789012:parameter/prod/key", want: "eu-central-1"},
+		{name: "Matching request", path: "arn:aws:secretsmanager:ap-south-1:123456789012:secret:db", requested: "ap-south-1", want: "ap-south-1"},
+		{name: "Conflicting request", path: "arn:aws:secretsmanager:ap-south-1:123456789012:secret:db", requested: "us-east-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := awsRegionFor(tt.path, tt.requested)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("awsRegionFor() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("awsRegionFor() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
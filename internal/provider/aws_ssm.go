@@ -0,0 +1,142 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// ssmAPI is the subset of the SSM client used by AWSSSMParameterStoreProvider.
+type ssmAPI interface {
+	GetParameter(ctx context.Context, params *ssm.GetParameterInput, optFns ...func(*ssm.Options)) (*ssm.GetParameterOutput, error)
+	ssm.GetParametersByPathAPIClient
+}
+
+// AWSSSMParameterStoreProvider implements SecretProvider for AWS Systems Manager
+// Parameter Store.
+//
+// A path naming a single parameter (e.g. "/prod/myapp/api-key") returns one
+// key named after the parameter's leaf name. A path ending in "/" (e.g.
+// "/prod/myapp/") returns every parameter below that prefix, recursively; keys
+// are the parameter names relative to the prefix with "/" replaced by "_".
+// SecureString parameters are always decrypted. A single parameter may also be
+// named by ARN, e.g. to read a parameter shared from another account.
+//
+// Regions and per-namespace roles apply as for AWSSecretsManagerProvider.
+type AWSSSMParameterStoreProvider struct {
+	clients *awsClients[ssmAPI]
+}
+
+// NewAWSSSMParameterStoreProvider creates a new AWS SSM Parameter Store provider.
+// It uses the default AWS configuration which respects AWS_PROFILE environment variable.
+func NewAWSSSMParameterStoreProvider(ctx context.Context) (*AWSSSMParameterStoreProvider, error) {
+	return NewAWSSSMParameterStoreProviderWithConfig(ctx, AWSConfig{})
+}
+
+// NewAWSSSMParameterStoreProviderWithConfig creates a new AWS SSM Parameter
+// Store provider using the region and role settings in cfg.
+func NewAWSSSMParameterStoreProviderWithConfig(ctx context.Context, cfg AWSConfig) (*AWSSSMParameterStoreProvider, error) {
+	clients, err := newAWSClients(ctx, cfg, func(awsCfg aws.Config) ssmAPI {
+		return ssm.NewFromConfig(awsCfg)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &AWSSSMParameterStoreProvider{clients: clients}, nil
+}
+
+// Name returns the provider identifier.
+func (p *AWSSSMParameterStoreProvider) Name() string {
+	return "aws-ssm"
+}
+
+// SupportedFetchOptions returns the FetchOptions settings read by
+// FetchSecret: only the region.
+func (p *AWSSSMParameterStoreProvider) SupportedFetchOptions() []string {
+	return []string{"region"}
+}
+
+// FetchSecret retrieves a single parameter, or every parameter under a path
+// prefix when path ends in "/".
+func (p *AWSSSMParameterStoreProvider) FetchSecret(ctx context.Context, path string) (map[string]string, error) {
+	if path == "" || path == "/" {
+		return nil, fmt.Errorf("ssm parameter path is empty")
+	}
+
+	region, err := awsRegionFor(path, FetchOptionsFrom(ctx).Region)
+	if err != nil {
+		return nil, err
+	}
+	client, err := p.clients.get(ctx, region)
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.HasSuffix(path, "/") {
+		return fetchSSMParametersByPath(ctx, client, path)
+	}
+	return fetchSSMParameter(ctx, client, path)
+}
+
+// fetchSSMParameter retrieves a single parameter keyed by its leaf name.
+func fetchSSMParameter(ctx context.Context, client ssmAPI, name string) (map[string]string, error) {
+	result, err := client.GetParameter(ctx, &ssm.GetParameterInput{
+		Name:           aws.String(name),
+		WithDecryption: aws.Bool(true),
+	})
+	if err != nil {
+		var notFound *ssmtypes.ParameterNotFound
+		if errors.As(err, &notFound) {
+			return nil, fmt.Errorf("ssm parameter %s not found (use a trailing \"/\" to fetch a path prefix)", name)
+		}
+		return nil, fmt.Errorf("failed to fetch parameter from AWS SSM: %w", err)
+	}
+
+	if result.Parameter == nil || result.Parameter.Value == nil {
+		return nil, fmt.Errorf("ssm parameter %s has no value", name)
+	}
+
+	leaf := name[strings.LastIndex(name, "/")+1:]
+	return map[string]string{leaf: *result.Parameter.Value}, nil
+}
+
+// fetchSSMParametersByPath retrieves every parameter below prefix, following pagination.
+func fetchSSMParametersByPath(ctx context.Context, client ssmAPI, prefix string) (map[string]string, error) {
+	paginator := ssm.NewGetParametersByPathPaginator(client, &ssm.GetParametersByPathInput{
+		Path:           aws.String(strings.TrimSuffix(prefix, "/")),
+		Recursive:      aws.Bool(true),
+		WithDecryption: aws.Bool(true),
+	})
+
+	secretData := make(map[string]string)
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch parameters from AWS SSM: %w", err)
+		}
+		for _, param := range page.Parameters {
+			if param.Name == nil || param.Value == nil {
+				continue
+			}
+			secretData[ssmParameterKey(prefix, *param.Name)] = *param.Value
+		}
+	}
+
+	if len(secretData) == 0 {
+		return nil, fmt.Errorf("no ssm parameters found under %s", prefix)
+	}
+	return secretData, nil
+}
+
+// ssmParameterKey derives a Kubernetes secret key from a parameter name
+// relative to prefix.
+func ssmParameterKey(prefix, name string) string {
+	relative := strings.TrimPrefix(strings.TrimPrefix(name, prefix), "/")
+	return strings.ReplaceAll(relative, "/", "_")
+}
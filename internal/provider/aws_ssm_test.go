@@ -0,0 +1,149 @@
+package provider
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	ssmtypes "github.com/aws/aws-sdk-go-v2/service/ssm/types"
+)
+
+// fakeSSM is an in-memory Parameter Store returning one parameter per page
+// to exercise pagination.
+type fakeSSM struct {
+	params map[string]string
+	order  []string
+	pages  int
+}
+
+func (f *fakeSSM) GetParameter(_ context.Context, in *ssm.GetParameterInput, _ ...func(*ssm.Options)) (*ssm.GetParameterOutput, error) {
+	value, ok := f.params[aws.ToString(in.Name)]
+	if !ok {
+		return nil, &ssmtypes.ParameterNotFound{}
+	}
+	return &ssm.GetParameterOutput{
+		Parameter: &ssmtypes.Parameter{Name: in.Name, Value: aws.String(value)},
+	}, nil
+}
+
+func (f *fakeSSM) GetParametersByPath(_ context.Context, in *ssm.GetParametersByPathInput, _ ...func(*ssm.Options)) (*ssm.GetParametersByPathOutput, error) {
+	f.pages++
+
+	var matching []string
+	for _, name := range f.order {
+		if strings.HasPrefix(name, aws.ToString(in.Path)+"/") {
+			matching = append(matching, name)
+		}
+	}
+
+	start := 0
+	if in.NextToken != nil {
+		for i, name := range matching {
+			if name == *in.NextToken {
+				start = i
+			}
+		}
+	}
+
+	out := &ssm.GetParametersByPathOutput{}
+	if start < len(matching) {
+		name := matching[start]
+		out.Parameters = []ssmtypes.Parameter{{Name: aws.String(name), Value: aws.String(f.params[name])}}
+		if start+1 < len(matching) {
+			out.NextToken = aws.String(matching[start+1])
+		}
+	}
+	return out, nil
+}
+
+func newFakeSSM() *fakeSSM {
+	f := &fakeSSM{params: map[string]string{}}
+	for _, kv := range [][2]string{
+		{"/prod/myapp/api-key", "key-123"},
+		{"/prod/myapp/db/username", "admin"},
+		{"/prod/myapp/db/password", "s3cret"},
+		{"/prod/other/token", "other"},
+	} {
+		f.params[kv[0]] = kv[1]
+		f.order = append(f.order, kv[0])
+	}
+	return f
+}
+
+func TestAWSSSMParameterStoreProvider_Name(t *testing.T) {
+	provider := &AWSSSMParameterStoreProvider{}
+	if got := provider.Name(); got != "aws-ssm" {
+		t.Errorf("Name() = %v, want %v", got, "aws-ssm")
+	}
+}
+
+func TestAWSSSMParameterStoreProvider_FetchSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "Single parameter",
+			path: "/prod/myapp/api-key",
+			want: map[string]string{"api-key": "key-123"},
+		},
+		{
+			name: "Path prefix with nested parameters",
+			path: "/prod/myapp/",
+			want: map[string]string{"api-key": "key-123", "db_username": "admin", "db_password": "s3cret"},
+		},
+		{
+			name:    "Missing parameter",
+			path:    "/prod/myapp/missing",
+			wantErr: true,
+		},
+		{
+			name:    "Empty prefix",
+			path:    "/prod/none/",
+			wantErr: true,
+		},
+		{
+			name:    "Empty path",
+			path:    "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &AWSSSMParameterStoreProvider{clients: staticAWSClients[ssmAPI](newFakeSSM())}
+
+			got, err := provider.FetchSecret(context.Background(), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d keys, want %d keys: %v", len(got), len(tt.want), got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("key %q: got %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestAWSSSMParameterStoreProvider_Paginates(t *testing.T) {
+	fake := newFakeSSM()
+	provider := &AWSSSMParameterStoreProvider{clients: staticAWSClients[ssmAPI](fake)}
+
+	if _, err := provider.FetchSecret(context.Background(), "/prod/myapp/"); err != nil {
+		t.Fatalf("FetchSecret() error = %v", err)
+	}
+	if fake.pages != 3 {
+		t.Errorf("pages fetched = %d, want 3", fake.pages)
+	}
+}
@@ -1,12 +1,46 @@
 package provider
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
-	"fmt"
 	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/secretsmanager"
 )
 
+// fakeSecretsManager is an in-memory secretsManagerAPI serving a binary
+// secret named "prod/keystore", a plain-text secret named "prod/api-token",
+// and for any other name a JSON secret with a current and a pending version.
+type fakeSecretsManager struct {
+	lastInput *secretsmanager.GetSecretValueInput
+}
+
+func (f *fakeSecretsManager) GetSecretValue(ctx context.Context, params *secretsmanager.GetSecretValueInput, optFns ...func(*secretsmanager.Options)) (*secretsmanager.GetSecretValueOutput, error) {
+	f.lastInput = params
+	if aws.ToString(params.SecretId) == "prod/keystore" {
+		return &secretsmanager.GetSecretValueOutput{
+			VersionId:    aws.String("v-binary"),
+			SecretBinary: []byte{0x00, 0xfe, 0xca, 0xfe},
+		}, nil
+	}
+	if aws.ToString(params.SecretId) == "prod/api-token" {
+		return &secretsmanager.GetSecretValueOutput{
+			VersionId:    aws.String("v-text"),
+			SecretString: aws.String("tok-123"),
+		}, nil
+	}
+	versionID, secretString := "v-current", `{"password":"current"}`
+	if aws.ToString(params.VersionStage) == "AWSPENDING" || aws.ToString(params.VersionId) == "v-pending" {
+		versionID, secretString = "v-pending", `{"password":"pending"}`
+	}
+	return &secretsmanager.GetSecretValueOutput{
+		VersionId:    aws.String(versionID),
+		SecretString: aws.String(secretString),
+	}, nil
+}
+
 func TestAWSSecretsManagerProvider_Name(t *testing.T) {
 	provider := &AWSSecretsManagerProvider{}
 	if got := provider.Name(); got != "aws-secretsmanager" {
@@ -83,23 +117,7 @@ func TestValueConversion(t *testing.T) {
 				return
 			}
 
-			// Convert all values to strings (same logic as in FetchSecret)
-			secretData := make(map[string]string, len(rawData))
-			for key, value := range rawData {
-				switch v := value.(type) {
-				case string:
-					secretData[key] = v
-				case float64:
-					secretData[key] = fmt.Sprintf("%v", v)
-				case bool:
-					secretData[key] = fmt.Sprintf("%v", v)
-				case nil:
-					secretData[key] = ""
-				default:
-					jsonBytes, _ := json.Marshal(v)
-					secretData[key] = string(jsonBytes)
-				}
-			}
+			secretData := stringifySecretData(rawData)
 
 			// Compare results
 			if len(secretData) != len(tt.want) {
@@ -130,7 +148,104 @@ func TestNewAWSSecretsManagerProvider(t *testing.T) {
 	if provider == nil {
 		t.Fatal("NewAWSSecretsManagerProvider() returned nil provider")
 	}
-	if provider.client == nil {
-		t.Error("NewAWSSecretsManagerProvider() client is nil")
+	if provider.clients == nil {
+		t.Error("NewAWSSecretsManagerProvider() clients is nil")
+	}
+}
+
+func TestAWSSecretsManagerProvider_FetchSecretValue(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        FetchOptions
+		wantVersion string
+		wantValue   string
+	}{
+		{name: "Current version", wantVersion: "v-current", wantValue: "current"},
+		{name: "Staging label", opts: FetchOptions{VersionStage: "AWSPENDING"}, wantVersion: "v-pending", wantValue: "pending"},
+		{name: "Version ID", opts: FetchOptions{Version: "v-pending"}, wantVersion: "v-pending", wantValue: "pending"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeSecretsManager{}
+			provider := &AWSSecretsManagerProvider{clients: staticAWSClients[secretsManagerAPI](fake)}
+
+			got, err := Fetch(WithFetchOptions(context.Background(), tt.opts), provider, "prod/db")
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if got.Version != tt.wantVersion {
+				t.Errorf("Version = %q, want %q", got.Version, tt.wantVersion)
+			}
+			if string(got.Data["password"]) != tt.wantValue {
+				t.Errorf("password = %q, want %q", got.Data["password"], tt.wantValue)
+			}
+			if aws.ToString(fake.lastInput.VersionId) != tt.opts.Version || aws.ToString(fake.lastInput.VersionStage) != tt.opts.VersionStage {
+				t.Errorf("input = %+v, want VersionId %q and VersionStage %q", fake.lastInput, tt.opts.Version, tt.opts.VersionStage)
+			}
+		})
+	}
+}
+
+func TestAWSSecretsManagerProvider_FetchSecretBinary(t *testing.T) {
+	provider := &AWSSecretsManagerProvider{clients: staticAWSClients[secretsManagerAPI](&fakeSecretsManager{})}
+
+	got, err := Fetch(context.Background(), provider, "prod/keystore")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if want := []byte{0x00, 0xfe, 0xca, 0xfe}; !bytes.Equal(got.Data["keystore"], want) {
+		t.Errorf("keystore = %x, want %x", got.Data["keystore"], want)
+	}
+}
+
+func TestAWSSecretBaseName(t *testing.T) {
+	tests := map[string]string{
+		"keystore":          "keystore",
+		"prod/app/keystore": "keystore",
+		"arn:aws:secretsmanager:us-east-1:123456789012:secret:prod/app/keystore-AbCdEf": "keystore",
+		"arn:aws:secretsmanager:us-east-1:123456789012:secret:my-keytab-AbCdEf":         "my-keytab",
+	}
+	for path, want := range tests {
+		if got := awsSecretBaseName(path); got != want {
+			t.Errorf("awsSecretBaseName(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestAWSSecretsManagerProvider_FetchSecretText(t *testing.T) {
+	provider := &AWSSecretsManagerProvider{clients: staticAWSClients[secretsManagerAPI](&fakeSecretsManager{})}
+
+	tests := []struct {
+		name    string
+		path    string
+		opts    FetchOptions
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "Plain text in JSON mode", path: "prod/api-token", wantErr: true},
+		{name: "Default key", path: "prod/api-token", opts: FetchOptions{Format: FormatText}, want: map[string]string{"api-token": "tok-123"}},
+		{name: "Custom key", path: "prod/api-token", opts: FetchOptions{Format: FormatText, TextKey: "token"}, want: map[string]string{"token": "tok-123"}},
+		{name: "JSON kept verbatim", path: "prod/db", opts: FetchOptions{Format: FormatText, TextKey: "config"}, want: map[string]string{"config": `{"password":"current"}`}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.FetchSecret(WithFetchOptions(context.Background(), tt.opts), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d keys, want %d keys: %v", len(got), len(tt.want), got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("key %q: got %q, want %q", k, got[k], v)
+				}
+			}
+		})
 	}
 }
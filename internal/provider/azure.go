@@ -0,0 +1,240 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// azureKeyVaultAPIVersion is the Key Vault data-plane API version used for requests.
+	azureKeyVaultAPIVersion = "7.4"
+	// azureKeyVaultScope is the OAuth2 scope for Key Vault access tokens.
+	azureKeyVaultScope = "https://vault.azure.net/.default"
+	// defaultAzureAuthorityHost is the Microsoft Entra ID endpoint for the public cloud.
+	defaultAzureAuthorityHost = "https://login.microsoftonline.com/"
+	// defaultAzureKeyVaultDNSSuffix is the Key Vault DNS suffix for the public cloud.
+	defaultAzureKeyVaultDNSSuffix = "vault.azure.net"
+	// azureTokenExpiryMargin is the tokenExpiry margin of Entra ID tokens,
+	// which live for an hour or more.
+	azureTokenExpiryMargin = 5 * time.Minute
+)
+
+// azureVaultNamePattern matches Key Vault names: 3-24 letters, digits and
+// hyphens, starting with a letter and not ending with a hyphen. Vault names
+// end up in the request host name, so anything else is rejected.
+var azureVaultNamePattern = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9-]{1,22}[a-zA-Z0-9]$`)
+
+// AzureKeyVaultConfig holds the settings for the Azure Key Vault provider.
+type AzureKeyVaultConfig struct {
+	// TenantID is the Microsoft Entra ID tenant.
+	TenantID string `yaml:"tenantId"`
+	// ClientID is the application (or managed identity) client ID.
+	ClientID string `yaml:"clientId"`
+	// ClientSecret authenticates with a client secret (optional).
+	ClientSecret string `yaml:"clientSecret"`
+	// FederatedTokenFile authenticates with workload identity by exchanging the
+	// projected ServiceAccount token in this file (optional).
+	FederatedTokenFile string `yaml:"federatedTokenFile"`
+	// AuthorityHost is the Microsoft Entra ID endpoint. Defaults to the public cloud.
+	AuthorityHost string `yaml:"authorityHost"`
+	// VaultDNSSuffix is the Key Vault DNS suffix. Defaults to "vault.azure.net".
+	VaultDNSSuffix string `yaml:"vaultDnsSuffix"`
+	// HTTPClient sends the Entra ID token and Key Vault requests (optional).
+	HTTPClient *http.Client `yaml:"-"`
+}
+
+// AzureKeyVaultConfigFromEnv builds an AzureKeyVaultConfig from the environment
+// variables used by the Azure SDKs and the workload identity webhook:
+// AZURE_TENANT_ID, AZURE_CLIENT_ID, AZURE_CLIENT_SECRET, AZURE_FEDERATED_TOKEN_FILE
+// and AZURE_AUTHORITY_HOST.
+func AzureKeyVaultConfigFromEnv() AzureKeyVaultConfig {
+	return AzureKeyVaultConfig{
+		TenantID:           os.Getenv("AZURE_TENANT_ID"),
+		ClientID:           os.Getenv("AZURE_CLIENT_ID"),
+		ClientSecret:       os.Getenv("AZURE_CLIENT_SECRET"),
+		FederatedTokenFile: os.Getenv("AZURE_FEDERATED_TOKEN_FILE"),
+		AuthorityHost:      os.Getenv("AZURE_AUTHORITY_HOST"),
+	}
+}
+
+// AzureKeyVaultProvider implements SecretProvider for Azure Key Vault.
+// Paths have the form "vault-name/secret-name[/version]".
+type AzureKeyVaultProvider struct {
+	cfg        AzureKeyVaultConfig
+	httpClient *http.Client
+	// vaultURL returns the base URL of the named vault.
+	vaultURL func(vaultName string) string
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewAzureKeyVaultProvider creates a new Azure Key Vault provider.
+// Either ClientSecret or FederatedTokenFile must be set.
+func NewAzureKeyVaultProvider(cfg AzureKeyVaultConfig) (*AzureKeyVaultProvider, error) {
+	if cfg.TenantID == "" {
+		return nil, fmt.Errorf("azure tenant ID is required")
+	}
+	if cfg.ClientID == "" {
+		return nil, fmt.Errorf("azure client ID is required")
+	}
+	if cfg.ClientSecret == "" && cfg.FederatedTokenFile == "" {
+		return nil, fmt.Errorf("azure client secret or federated token file is required")
+	}
+	if cfg.AuthorityHost == "" {
+		cfg.AuthorityHost = defaultAzureAuthorityHost
+	}
+	if cfg.VaultDNSSuffix == "" {
+		cfg.VaultDNSSuffix = defaultAzureKeyVaultDNSSuffix
+	}
+
+	dnsSuffix := cfg.VaultDNSSuffix
+	return &AzureKeyVaultProvider{
+		cfg:        cfg,
+		httpClient: httpClientOrDefault(cfg.HTTPClient),
+		vaultURL: func(vaultName string) string {
+			return fmt.Sprintf("https://%s.%s", vaultName, dnsSuffix)
+		},
+	}, nil
+}
+
+// Name returns the provider identifier.
+func (p *AzureKeyVaultProvider) Name() string {
+	return "azure-keyvault"
+}
+
+// FetchSecret retrieves a secret from Azure Key Vault.
+// A secret value holding a JSON object is expanded into its keys; any other
+// value is returned under the secret name.
+func (p *AzureKeyVaultProvider) FetchSecret(ctx context.Context, path string) (map[string]string, error) {
+	vaultName, secretName, version, err := parseAzureSecretPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secretURL := fmt.Sprintf("%s/secrets/%s", p.vaultURL(vaultName), url.PathEscape(secretName))
+	if version != "" {
+		secretURL += "/" + url.PathEscape(version)
+	}
+	secretURL += "?api-version=" + azureKeyVaultAPIVersion
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, secretURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure Key Vault request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var response struct {
+		Value *string `json:"value"`
+	}
+	if err := doJSON(p.httpClient, req, &response, azureErrorMessage); err != nil {
+		return nil, fmt.Errorf("failed to fetch secret from Azure Key Vault: %w", err)
+	}
+	if response.Value == nil {
+		return nil, fmt.Errorf("secret %s does not contain a value", path)
+	}
+
+	return parseSecretPayload(*response.Value, secretName), nil
+}
+
+// parseAzureSecretPath splits "vault-name/secret-name[/version]".
+func parseAzureSecretPath(path string) (vaultName, secretName, version string, err error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return "", "", "", fmt.Errorf("invalid Azure Key Vault path %q: expected vault-name/secret-name[/version]", path)
+	}
+	if !azureVaultNamePattern.MatchString(parts[0]) {
+		return "", "", "", fmt.Errorf("invalid Azure Key Vault name %q", parts[0])
+	}
+	if len(parts) == 3 {
+		version = parts[2]
+	}
+	return parts[0], parts[1], version, nil
+}
+
+// token returns a cached access token for Key Vault, requesting a new one
+// from Microsoft Entra ID when it is missing or about to expire.
+func (p *AzureKeyVaultProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.accessToken != "" && time.Now().Before(p.expiresAt) {
+		return p.accessToken, nil
+	}
+
+	form := url.Values{
+		"grant_type": {"client_credentials"},
+		"client_id":  {p.cfg.ClientID},
+		"scope":      {azureKeyVaultScope},
+	}
+	if p.cfg.FederatedTokenFile != "" {
+		// Workload identity: exchange the projected ServiceAccount token.
+		// The file is re-read on every request because kubelet rotates it.
+		assertion, err := os.ReadFile(p.cfg.FederatedTokenFile)
+		if err != nil {
+			return "", fmt.Errorf("failed to read federated token file: %w", err)
+		}
+		form.Set("client_assertion_type", "urn:ietf:params:oauth:client-assertion-type:jwt-bearer")
+		form.Set("client_assertion", strings.TrimSpace(string(assertion)))
+	} else {
+		form.Set("client_secret", p.cfg.ClientSecret)
+	}
+
+	tokenURL := fmt.Sprintf("%s/%s/oauth2/v2.0/token", strings.TrimRight(p.cfg.AuthorityHost, "/"), url.PathEscape(p.cfg.TenantID))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", fmt.Errorf("failed to create Azure token request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := doJSON(p.httpClient, req, &response, azureErrorMessage); err != nil {
+		return "", fmt.Errorf("failed to acquire Azure access token: %w", err)
+	}
+	if response.AccessToken == "" {
+		return "", fmt.Errorf("azure token response did not contain an access token")
+	}
+
+	p.accessToken = response.AccessToken
+	p.expiresAt = tokenExpiry(response.ExpiresIn, azureTokenExpiryMargin)
+	return p.accessToken, nil
+}
+
+// azureErrorMessage extracts the message of an Entra ID or Key Vault error
+// response.
+func azureErrorMessage(body []byte) string {
+	var errResponse struct {
+		Error json.RawMessage `json:"error"`
+		// Entra ID token errors
+		ErrorDescription string `json:"error_description"`
+	}
+	_ = json.Unmarshal(body, &errResponse)
+	if errResponse.ErrorDescription != "" {
+		return errResponse.ErrorDescription
+	}
+	var kvError struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	}
+	if json.Unmarshal(errResponse.Error, &kvError) == nil && kvError.Message != "" {
+		return kvError.Code + ": " + kvError.Message
+	}
+	return ""
+}
@@ -0,0 +1,217 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newFakeAzure starts an httptest server that mimics both the Microsoft Entra ID
+// token endpoint and a Key Vault named "myvault".
+func newFakeAzure(t *testing.T, tokenRequests *int) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/tenant-1/oauth2/v2.0/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("invalid token request: %v", err)
+		}
+		*tokenRequests++
+		if r.Form.Get("scope") != azureKeyVaultScope || r.Form.Get("client_id") != "client-1" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_request","error_description":"bad scope or client"}`))
+			return
+		}
+		secretOK := r.Form.Get("client_secret") == "s3cret"
+		assertionOK := r.Form.Get("client_assertion") == "federated-jwt" &&
+			r.Form.Get("client_assertion_type") == "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+		if !secretOK && !assertionOK {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"invalid_client","error_description":"invalid credentials"}`))
+			return
+		}
+		w.Write([]byte(`{"access_token":"kv-token","expires_in":3600,"token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("/myvault/secrets/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer kv-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Query().Get("api-version") != azureKeyVaultAPIVersion {
+			t.Errorf("unexpected api-version %q", r.URL.Query().Get("api-version"))
+		}
+		switch r.URL.Path {
+		case "/myvault/secrets/db-creds":
+			w.Write([]byte(`{"value":"{\"username\":\"admin\",\"port\":5432}","id":"https://myvault.vault.azure.net/secrets/db-creds/abc"}`))
+		case "/myvault/secrets/api-token/v1":
+			w.Write([]byte(`{"value":"token-v1","id":"https://myvault.vault.azure.net/secrets/api-token/v1"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":"SecretNotFound","message":"A secret with the given name was not found"}}`))
+		}
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func newTestAzureProvider(t *testing.T, server *httptest.Server, cfg AzureKeyVaultConfig) *AzureKeyVaultProvider {
+	t.Helper()
+
+	cfg.TenantID = "tenant-1"
+	cfg.ClientID = "client-1"
+	cfg.AuthorityHost = server.URL
+	provider, err := NewAzureKeyVaultProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewAzureKeyVaultProvider() error = %v", err)
+	}
+	provider.vaultURL = func(vaultName string) string {
+		return server.URL + "/" + vaultName
+	}
+	return provider
+}
+
+func TestAzureKeyVaultProvider_Name(t *testing.T) {
+	provider := &AzureKeyVaultProvider{}
+	if got := provider.Name(); got != "azure-keyvault" {
+		t.Errorf("Name() = %v, want %v", got, "azure-keyvault")
+	}
+}
+
+func TestAzureKeyVaultProvider_FetchSecret(t *testing.T) {
+	var tokenRequests int
+	server := newFakeAzure(t, &tokenRequests)
+	provider := newTestAzureProvider(t, server, AzureKeyVaultConfig{ClientSecret: "s3cret"})
+
+	tests := []struct {
+		name    string
+		path    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "JSON secret latest version",
+			path: "myvault/db-creds",
+			want: map[string]string{"username": "admin", "port": "5432"},
+		},
+		{
+			name: "Plain secret pinned version",
+			path: "myvault/api-token/v1",
+			want: map[string]string{"api-token": "token-v1"},
+		},
+		{
+			name:    "Missing secret",
+			path:    "myvault/missing",
+			wantErr: true,
+		},
+		{
+			name:    "Path without secret name",
+			path:    "myvault",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.FetchSecret(context.Background(), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d keys, want %d keys", len(got), len(tt.want))
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("key %q: got %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+
+	if tokenRequests != 1 {
+		t.Errorf("token requests = %d, want 1 (token should be cached)", tokenRequests)
+	}
+}
+
+func TestParseAzureSecretPath(t *testing.T) {
+	vaultName, secretName, version, err := parseAzureSecretPath("my-vault-01/db-creds/v1")
+	if err != nil {
+		t.Fatalf("parseAzureSecretPath() error = %v", err)
+	}
+	if vaultName != "my-vault-01" || secretName != "db-creds" || version != "v1" {
+		t.Errorf("parseAzureSecretPath() = %q, %q, %q", vaultName, secretName, version)
+	}
+
+	for _, path := range []string{
+		"attacker.example?/x",
+		"attacker.example/x",
+		"vault@attacker.example/x",
+		"vault#fragment/x",
+		"vault?query/x",
+		"attacker.example%2Fpath/x",
+		"1vault/x",
+		"vault-/x",
+		"ab/x",
+		"a-vault-name-longer-than-24/x",
+	} {
+		if _, _, _, err := parseAzureSecretPath(path); err == nil || !strings.Contains(err.Error(), "invalid Azure Key Vault name") {
+			t.Errorf("parseAzureSecretPath(%q) error = %v, want invalid vault name", path, err)
+		}
+	}
+}
+
+func TestAzureKeyVaultProvider_WorkloadIdentity(t *testing.T) {
+	var tokenRequests int
+	server := newFakeAzure(t, &tokenRequests)
+
+	tokenFile := filepath.Join(t.TempDir(), "azure-identity-token")
+	if err := os.WriteFile(tokenFile, []byte("federated-jwt\n"), 0o600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+	provider := newTestAzureProvider(t, server, AzureKeyVaultConfig{FederatedTokenFile: tokenFile})
+
+	got, err := provider.FetchSecret(context.Background(), "myvault/api-token/v1")
+	if err != nil {
+		t.Fatalf("FetchSecret() error = %v", err)
+	}
+	if got["api-token"] != "token-v1" {
+		t.Errorf("api-token = %q, want %q", got["api-token"], "token-v1")
+	}
+}
+
+func TestAzureKeyVaultProvider_InvalidCredentials(t *testing.T) {
+	var tokenRequests int
+	server := newFakeAzure(t, &tokenRequests)
+	provider := newTestAzureProvider(t, server, AzureKeyVaultConfig{ClientSecret: "wrong"})
+
+	if _, err := provider.FetchSecret(context.Background(), "myvault/db-creds"); err == nil {
+		t.Error("FetchSecret() expected error for invalid credentials")
+	}
+}
+
+func TestNewAzureKeyVaultProvider_Validation(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  AzureKeyVaultConfig
+	}{
+		{name: "Missing tenant", cfg: AzureKeyVaultConfig{ClientID: "c", ClientSecret: "s"}},
+		{name: "Missing client", cfg: AzureKeyVaultConfig{TenantID: "t", ClientSecret: "s"}},
+		{name: "Missing credential", cfg: AzureKeyVaultConfig{TenantID: "t", ClientID: "c"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewAzureKeyVaultProvider(tt.cfg); err == nil {
+				t.Error("NewAzureKeyVaultProvider() expected error")
+			}
+		})
+	}
+}
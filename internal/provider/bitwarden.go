@@ -0,0 +1,405 @@
+package provider
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hkdf"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultBitwardenAPIURL is the Bitwarden cloud API endpoint.
+	defaultBitwardenAPIURL = "https://api.bitwarden.com"
+	// defaultBitwardenIdentityURL is the Bitwarden cloud identity endpoint.
+	defaultBitwardenIdentityURL = "https://identity.bitwarden.com"
+	// bitwardenTokenExpiryMargin is the tokenExpiry margin of machine account
+	// access tokens.
+	bitwardenTokenExpiryMargin = time.Minute
+)
+
+// BitwardenConfig holds the settings for the Bitwarden Secrets Manager provider.
+type BitwardenConfig struct {
+	// AccessToken is a machine account access token
+	// ("0.<id>.<client-secret>:<encryption-key>").
+	AccessToken string `yaml:"accessToken"`
+	// APIURL is the Bitwarden API endpoint. Defaults to the Bitwarden cloud.
+	APIURL string `yaml:"apiUrl"`
+	// IdentityURL is the Bitwarden identity endpoint. Defaults to the Bitwarden cloud.
+	IdentityURL string `yaml:"identityUrl"`
+	// HTTPClient sends the identity and API requests (optional).
+	HTTPClient *http.Client `yaml:"-"`
+}
+
+// BitwardenConfigFromEnv builds a BitwardenConfig from the environment
+// variables used by the bws CLI: BWS_ACCESS_TOKEN and, for self-hosted
+// servers, BWS_SERVER_URL (the API and identity URLs are derived from it).
+func BitwardenConfigFromEnv() BitwardenConfig {
+	cfg := BitwardenConfig{AccessToken: os.Getenv("BWS_ACCESS_TOKEN")}
+	if serverURL := strings.TrimRight(os.Getenv("BWS_SERVER_URL"), "/"); serverURL != "" {
+		cfg.APIURL = serverURL + "/api"
+		cfg.IdentityURL = serverURL + "/identity"
+	}
+	return cfg
+}
+
+// bitwardenKey is an AES-256-CBC encryption key with its HMAC-SHA256 key.
+type bitwardenKey struct {
+	encKey []byte
+	macKey []byte
+}
+
+// BitwardenProvider implements SecretProvider for Bitwarden Secrets Manager.
+// Paths have the form "project/key" to fetch a single secret, or "project"
+// to fetch every secret in the project. The project may be a name or an ID.
+//
+// Secret keys and values are end-to-end encrypted; they are decrypted locally
+// with the organization key unwrapped from the machine account access token.
+// The login and API calls follow the Bitwarden SDK, whose Go bindings are not
+// used because they need cgo and a native library.
+type BitwardenProvider struct {
+	apiURL       string
+	identityURL  string
+	httpClient   *http.Client
+	clientID     string
+	clientSecret string
+	tokenKey     bitwardenKey
+
+	mu             sync.Mutex
+	accessToken    string
+	expiresAt      time.Time
+	organizationID string
+	orgKey         bitwardenKey
+}
+
+// NewBitwardenProvider creates a new Bitwarden Secrets Manager provider.
+func NewBitwardenProvider(cfg BitwardenConfig) (*BitwardenProvider, error) {
+	clientID, clientSecret, tokenKey, err := parseBitwardenAccessToken(cfg.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+
+	apiURL := cfg.APIURL
+	if apiURL == "" {
+		apiURL = defaultBitwardenAPIURL
+	}
+	identityURL := cfg.IdentityURL
+	if identityURL == "" {
+		identityURL = defaultBitwardenIdentityURL
+	}
+
+	return &BitwardenProvider{
+		apiURL:       strings.TrimRight(apiURL, "/"),
+		identityURL:  strings.TrimRight(identityURL, "/"),
+		httpClient:   httpClientOrDefault(cfg.HTTPClient),
+		clientID:     clientID,
+		clientSecret: clientSecret,
+		tokenKey:     tokenKey,
+	}, nil
+}
+
+// Name returns the provider identifier.
+func (p *BitwardenProvider) Name() string {
+	return "bitwarden"
+}
+
+// bitwardenSecretSummary is a secret as returned by list endpoints.
+type bitwardenSecretSummary struct {
+	ID  string `json:"id"`
+	Key string `json:"key"`
+}
+
+// FetchSecret fetches one secret ("project/key") or all secrets of a project.
+func (p *BitwardenProvider) FetchSecret(ctx context.Context, path string) (map[string]string, error) {
+	projectRef, keyName, _ := strings.Cut(strings.Trim(path, "/"), "/")
+	if projectRef == "" {
+		return nil, fmt.Errorf("invalid Bitwarden path %q: expected project[/key]", path)
+	}
+
+	token, orgID, orgKey, err := p.session(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	projectID, err := p.resolveProject(ctx, token, orgID, orgKey, projectRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var list struct {
+		Secrets []bitwardenSecretSummary `json:"secrets"`
+	}
+	if err := p.do(ctx, http.MethodGet, p.apiURL+"/projects/"+url.PathEscape(projectID)+"/secrets", token, nil, &list); err != nil {
+		return nil, fmt.Errorf("failed to list Bitwarden secrets: %w", err)
+	}
+
+	var ids []string
+	for _, secret := range list.Secrets {
+		if keyName != "" {
+			name, err := decryptBitwardenString(secret.Key, orgKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to decrypt secret key: %w", err)
+			}
+			if name != keyName {
+				continue
+			}
+		}
+		ids = append(ids, secret.ID)
+	}
+	if len(ids) == 0 {
+		if keyName != "" {
+			return nil, fmt.Errorf("secret %s not found in Bitwarden project %s", keyName, projectRef)
+		}
+		return nil, fmt.Errorf("bitwarden project %s has no secrets", projectRef)
+	}
+
+	var secrets struct {
+		Data []struct {
+			Key   string `json:"key"`
+			Value string `json:"value"`
+		} `json:"data"`
+	}
+	if err := p.do(ctx, http.MethodPost, p.apiURL+"/secrets/get-by-ids", token, map[string][]string{"ids": ids}, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to fetch Bitwarden secrets: %w", err)
+	}
+
+	secretData := make(map[string]string, len(secrets.Data))
+	for _, secret := range secrets.Data {
+		name, err := decryptBitwardenString(secret.Key, orgKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secret key: %w", err)
+		}
+		value, err := decryptBitwardenString(secret.Value, orgKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt value of secret %s: %w", name, err)
+		}
+		secretData[name] = value
+	}
+	return secretData, nil
+}
+
+// resolveProject returns the ID of the project named or identified by ref.
+func (p *BitwardenProvider) resolveProject(ctx context.Context, token, orgID string, orgKey bitwardenKey, ref string) (string, error) {
+	var projects struct {
+		Data []struct {
+			ID   string `json:"id"`
+			Name string `json:"name"`
+		} `json:"data"`
+	}
+	if err := p.do(ctx, http.MethodGet, p.apiURL+"/organizations/"+url.PathEscape(orgID)+"/projects", token, nil, &projects); err != nil {
+		return "", fmt.Errorf("failed to list Bitwarden projects: %w", err)
+	}
+
+	for _, project := range projects.Data {
+		if project.ID == ref {
+			return project.ID, nil
+		}
+		name, err := decryptBitwardenString(project.Name, orgKey)
+		if err != nil {
+			return "", fmt.Errorf("failed to decrypt project name: %w", err)
+		}
+		if name == ref {
+			return project.ID, nil
+		}
+	}
+	return "", fmt.Errorf("bitwarden project %s not found or not accessible", ref)
+}
+
+// session returns a valid access token along with the organization ID and
+// key, logging in with the machine account when needed.
+func (p *BitwardenProvider) session(ctx context.Context) (string, string, bitwardenKey, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.accessToken != "" && time.Now().Before(p.expiresAt) {
+		return p.accessToken, p.organizationID, p.orgKey, nil
+	}
+
+	form := url.Values{
+		"scope":         {"api.secrets"},
+		"client_id":     {p.clientID},
+		"client_secret": {p.clientSecret},
+		"grant_type":    {"client_credentials"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.identityURL+"/connect/token", strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", "", bitwardenKey{}, fmt.Errorf("failed to create Bitwarden login request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var response struct {
+		AccessToken      string `json:"access_token"`
+		ExpiresIn        int    `json:"expires_in"`
+		EncryptedPayload string `json:"encrypted_payload"`
+	}
+	if err := doJSON(p.httpClient, req, &response, bitwardenErrorMessage); err != nil {
+		return "", "", bitwardenKey{}, fmt.Errorf("bitwarden login failed: %w", err)
+	}
+
+	payload, err := decryptBitwardenString(response.EncryptedPayload, p.tokenKey)
+	if err != nil {
+		return "", "", bitwardenKey{}, fmt.Errorf("failed to decrypt Bitwarden login payload: %w", err)
+	}
+	var keyPayload struct {
+		EncryptionKey string `json:"encryptionKey"`
+	}
+	if err := json.Unmarshal([]byte(payload), &keyPayload); err != nil {
+		return "", "", bitwardenKey{}, fmt.Errorf("failed to parse Bitwarden login payload: %w", err)
+	}
+	rawOrgKey, err := base64.StdEncoding.DecodeString(keyPayload.EncryptionKey)
+	if err != nil || len(rawOrgKey) != 64 {
+		return "", "", bitwardenKey{}, fmt.Errorf("invalid Bitwarden organization key")
+	}
+
+	orgID, err := bitwardenOrganizationID(response.AccessToken)
+	if err != nil {
+		return "", "", bitwardenKey{}, err
+	}
+
+	p.accessToken = response.AccessToken
+	p.expiresAt = tokenExpiry(response.ExpiresIn, bitwardenTokenExpiryMargin)
+	p.organizationID = orgID
+	p.orgKey = bitwardenKey{encKey: rawOrgKey[:32], macKey: rawOrgKey[32:]}
+	return p.accessToken, p.organizationID, p.orgKey, nil
+}
+
+// do sends an authenticated API request and decodes the JSON response into out.
+func (p *BitwardenProvider) do(ctx context.Context, method, reqURL, token string, body interface{}, out interface{}) error {
+	req, err := newJSONRequest(ctx, method, reqURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return doJSON(p.httpClient, req, out, bitwardenErrorMessage)
+}
+
+// bitwardenErrorMessage extracts the message of an API or identity error
+// response.
+func bitwardenErrorMessage(body []byte) string {
+	var errResponse struct {
+		Message          string `json:"message"`
+		ErrorDescription string `json:"error_description"`
+	}
+	_ = json.Unmarshal(body, &errResponse)
+	return errResponse.Message + errResponse.ErrorDescription
+}
+
+// parseBitwardenAccessToken splits a machine account access token
+// "0.<client-id>.<client-secret>:<base64 key>" and derives the key used to
+// decrypt the login payload.
+func parseBitwardenAccessToken(accessToken string) (clientID, clientSecret string, key bitwardenKey, err error) {
+	credentials, encodedKey, found := strings.Cut(accessToken, ":")
+	parts := strings.Split(credentials, ".")
+	if !found || len(parts) != 3 || parts[0] != "0" || parts[1] == "" || parts[2] == "" {
+		return "", "", bitwardenKey{}, fmt.Errorf("invalid Bitwarden access token format")
+	}
+
+	secret, err := base64.StdEncoding.DecodeString(encodedKey)
+	if err != nil || len(secret) != 16 {
+		return "", "", bitwardenKey{}, fmt.Errorf("invalid Bitwarden access token encryption key")
+	}
+
+	key, err = deriveBitwardenShareableKey(secret, "accesstoken", "sm-access-token")
+	if err != nil {
+		return "", "", bitwardenKey{}, fmt.Errorf("failed to derive Bitwarden access token key: %w", err)
+	}
+	return parts[1], parts[2], key, nil
+}
+
+// deriveBitwardenShareableKey derives a key from secret like the Bitwarden
+// SDK's derive_shareable_key: an HMAC-SHA256 keyed with "bitwarden-<name>"
+// extracts a pseudorandom key, which HKDF expands with info.
+func deriveBitwardenShareableKey(secret []byte, name, info string) (bitwardenKey, error) {
+	mac := hmac.New(sha256.New, []byte("bitwarden-"+name))
+	mac.Write(secret)
+	derived, err := hkdf.Expand(sha256.New, mac.Sum(nil), info, 64)
+	if err != nil {
+		return bitwardenKey{}, err
+	}
+	return bitwardenKey{encKey: derived[:32], macKey: derived[32:]}, nil
+}
+
+// bitwardenOrganizationID extracts the "organization" claim from an access token JWT.
+func bitwardenOrganizationID(accessToken string) (string, error) {
+	parts := strings.Split(accessToken, ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("invalid Bitwarden access token JWT")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return "", fmt.Errorf("invalid Bitwarden access token JWT: %w", err)
+	}
+	var claims struct {
+		Organization string `json:"organization"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil || claims.Organization == "" {
+		return "", fmt.Errorf("bitwarden access token has no organization claim")
+	}
+	return claims.Organization, nil
+}
+
+// decryptBitwardenString decrypts a type 2 EncString
+// ("2.<iv>|<ciphertext>|<mac>", AES-256-CBC with HMAC-SHA256).
+func decryptBitwardenString(encString string, key bitwardenKey) (string, error) {
+	encType, rest, found := strings.Cut(encString, ".")
+	if !found || encType != "2" {
+		return "", fmt.Errorf("unsupported encryption type")
+	}
+	parts := strings.Split(rest, "|")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed encrypted string")
+	}
+
+	var decoded [3][]byte
+	for i, part := range parts {
+		b, err := base64.StdEncoding.DecodeString(part)
+		if err != nil {
+			return "", fmt.Errorf("malformed encrypted string: %w", err)
+		}
+		decoded[i] = b
+	}
+	plaintext, err := decryptBitwardenCBC(decoded[0], decoded[1], decoded[2], key)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// decryptBitwardenCBC checks the HMAC-SHA256 tag over iv and data, then
+// decrypts data with AES-CBC. The tag is checked first and in constant time,
+// so that nothing is decrypted from a forged ciphertext and timing reveals
+// nothing about the expected tag.
+func decryptBitwardenCBC(iv, data, tag []byte, key bitwardenKey) ([]byte, error) {
+	mac := hmac.New(sha256.New, key.macKey)
+	mac.Write(iv)
+	mac.Write(data)
+	if !hmac.Equal(mac.Sum(nil), tag) {
+		return nil, fmt.Errorf("MAC mismatch")
+	}
+
+	block, err := aes.NewCipher(key.encKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(iv) != aes.BlockSize || len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return nil, fmt.Errorf("malformed ciphertext")
+	}
+	plaintext := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(plaintext, data)
+
+	padding := int(plaintext[len(plaintext)-1])
+	if padding == 0 || padding > aes.BlockSize || padding > len(plaintext) {
+		return nil, fmt.Errorf("invalid padding")
+	}
+	return plaintext[:len(plaintext)-padding], nil
+}
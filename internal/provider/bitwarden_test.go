@@ -0,0 +1,337 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// bitwardenEncrypt produces a type 2 EncString the way Bitwarden clients do.
+func bitwardenEncrypt(t *testing.T, plaintext string, key bitwardenKey) string {
+	t.Helper()
+
+	iv := make([]byte, aes.BlockSize)
+	if _, err := rand.Read(iv); err != nil {
+		t.Fatal(err)
+	}
+	padding := aes.BlockSize - len(plaintext)%aes.BlockSize
+	padded := append([]byte(plaintext), bytes.Repeat([]byte{byte(padding)}, padding)...)
+
+	block, err := aes.NewCipher(key.encKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(data, padded)
+
+	mac := hmac.New(sha256.New, key.macKey)
+	mac.Write(iv)
+	mac.Write(data)
+
+	return "2." + base64.StdEncoding.EncodeToString(iv) + "|" +
+		base64.StdEncoding.EncodeToString(data) + "|" +
+		base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// newFakeBitwarden starts an httptest server mimicking the Bitwarden identity
+// and API endpoints, and returns it with a matching machine account token.
+func newFakeBitwarden(t *testing.T) (*httptest.Server, string, *int) {
+	t.Helper()
+
+	tokenSecret := make([]byte, 16)
+	if _, err := rand.Read(tokenSecret); err != nil {
+		t.Fatal(err)
+	}
+	accessToken := "0.client-1.client-secret:" + base64.StdEncoding.EncodeToString(tokenSecret)
+	_, _, tokenKey, err := parseBitwardenAccessToken(accessToken)
+	if err != nil {
+		t.Fatalf("parseBitwardenAccessToken() error = %v", err)
+	}
+
+	rawOrgKey := make([]byte, 64)
+	if _, err := rand.Read(rawOrgKey); err != nil {
+		t.Fatal(err)
+	}
+	orgKey := bitwardenKey{encKey: rawOrgKey[:32], macKey: rawOrgKey[32:]}
+
+	secrets := map[string][2]string{
+		"s1": {"DB_PASSWORD", "s3cret"},
+		"s2": {"API_KEY", "key-123"},
+	}
+	jwtPayload := base64.RawURLEncoding.EncodeToString([]byte(`{"organization":"org-1","client_id":"client-1"}`))
+	jwt := "eyJhbGciOiJub25lIn0." + jwtPayload + ".sig"
+
+	logins := 0
+	mux := http.NewServeMux()
+	mux.HandleFunc("/identity/connect/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("invalid login request: %v", err)
+		}
+		if r.Form.Get("client_id") != "client-1" || r.Form.Get("client_secret") != "client-secret" || r.Form.Get("scope") != "api.secrets" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_client","error_description":"invalid client"}`))
+			return
+		}
+		logins++
+		payload := `{"encryptionKey":"` + base64.StdEncoding.EncodeToString(rawOrgKey) + `"}`
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"access_token":      jwt,
+			"expires_in":        3600,
+			"token_type":        "Bearer",
+			"encrypted_payload": bitwardenEncrypt(t, payload, tokenKey),
+		})
+	})
+	mux.HandleFunc("/api/organizations/org-1/projects", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []map[string]string{
+				{"id": "proj-1", "name": bitwardenEncrypt(t, "backend", orgKey)},
+			},
+		})
+	})
+	mux.HandleFunc("/api/projects/proj-1/secrets", func(w http.ResponseWriter, r *http.Request) {
+		var list []map[string]string
+		for _, id := range []string{"s1", "s2"} {
+			list = append(list, map[string]string{"id": id, "key": bitwardenEncrypt(t, secrets[id][0], orgKey)})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"secrets": list})
+	})
+	mux.HandleFunc("/api/secrets/get-by-ids", func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			IDs []string `json:"ids"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid get-by-ids body: %v", err)
+		}
+		var data []map[string]string
+		for _, id := range body.IDs {
+			data = append(data, map[string]string{
+				"id":    id,
+				"key":   bitwardenEncrypt(t, secrets[id][0], orgKey),
+				"value": bitwardenEncrypt(t, secrets[id][1], orgKey),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/identity/connect/token" && r.Header.Get("Authorization") != "Bearer "+jwt {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server, accessToken, &logins
+}
+
+func TestBitwardenProvider_Name(t *testing.T) {
+	provider := &BitwardenProvider{}
+	if got := provider.Name(); got != "bitwarden" {
+		t.Errorf("Name() = %v, want %v", got, "bitwarden")
+	}
+}
+
+func TestBitwardenProvider_FetchSecret(t *testing.T) {
+	server, accessToken, logins := newFakeBitwarden(t)
+
+	provider, err := NewBitwardenProvider(BitwardenConfig{
+		AccessToken: accessToken,
+		APIURL:      server.URL + "/api",
+		IdentityURL: server.URL + "/identity",
+	})
+	if err != nil {
+		t.Fatalf("NewBitwardenProvider() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "Single secret by project name",
+			path: "backend/DB_PASSWORD",
+			want: map[string]string{"DB_PASSWORD": "s3cret"},
+		},
+		{
+			name: "Single secret by project ID",
+			path: "proj-1/API_KEY",
+			want: map[string]string{"API_KEY": "key-123"},
+		},
+		{
+			name: "Whole project",
+			path: "backend",
+			want: map[string]string{"DB_PASSWORD": "s3cret", "API_KEY": "key-123"},
+		},
+		{
+			name:    "Missing key",
+			path:    "backend/MISSING",
+			wantErr: true,
+		},
+		{
+			name:    "Missing project",
+			path:    "frontend/API_KEY",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.FetchSecret(context.Background(), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d keys, want %d keys: %v", len(got), len(tt.want), got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("key %q: got %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+
+	if *logins != 1 {
+		t.Errorf("logins = %d, want 1 (session should be cached)", *logins)
+	}
+}
+
+func TestDecryptBitwardenString_TamperedMAC(t *testing.T) {
+	key := bitwardenKey{encKey: make([]byte, 32), macKey: make([]byte, 32)}
+	encrypted := bitwardenEncrypt(t, "value", key)
+
+	otherKey := bitwardenKey{encKey: make([]byte, 32), macKey: bytes.Repeat([]byte{1}, 32)}
+	if _, err := decryptBitwardenString(encrypted, otherKey); err == nil {
+		t.Error("decryptBitwardenString() expected MAC mismatch error")
+	}
+	if got, err := decryptBitwardenString(encrypted, key); err != nil || got != "value" {
+		t.Errorf("decryptBitwardenString() = %q, %v; want %q", got, err, "value")
+	}
+}
+
+// The vectors below are the Bitwarden SDK's own test vectors, except for
+// the AES-256 EncString, which was produced with OpenSSL.
+
+func TestDeriveBitwardenShareableKey(t *testing.T) {
+	tests := []struct {
+		secret string
+		info   string
+		want   string
+	}{
+		{
+			secret: "&/$%F1a895g67HlX",
+			want:   "4PV6+PcmF2w7YHRatvyMcVQtI7zvCyssv/wFWmzjiH6Iv9altjmDkuBD1aagLVaLezbthbSe+ktR+U6qswxNnQ==",
+		},
+		{
+			secret: "67t9b5g67$%Dh89n",
+			info:   "test",
+			want:   "F9jVQmrACGx9VUPjuzfMYDjr726JtL300Y3Yg+VYUnVQtQ1s8oImJ5xtp1KALC9h2nav04++1LDW4iFD+infng==",
+		},
+	}
+
+	for _, tt := range tests {
+		key, err := deriveBitwardenShareableKey([]byte(tt.secret), "test_key", tt.info)
+		if err != nil {
+			t.Fatalf("deriveBitwardenShareableKey() error = %v", err)
+		}
+		if got := base64.StdEncoding.EncodeToString(append(key.encKey, key.macKey...)); got != tt.want {
+			t.Errorf("deriveBitwardenShareableKey(%q, %q) = %s, want %s", tt.secret, tt.info, got, tt.want)
+		}
+	}
+}
+
+func TestDecryptBitwardenCBC_KnownVectors(t *testing.T) {
+	tests := []struct {
+		name      string
+		key       string
+		encString string
+		want      string
+	}{
+		{
+			// Type 1 (AES-128-CBC with HMAC-SHA256) from the Bitwarden SDK;
+			// type 2 differs only by its key size.
+			name:      "AES-128",
+			key:       "Gt1aZ8kTTgkF80bLtb7LiMZBcxEA2FA5mbvV4x7K208=",
+			encString: "CU/oG4VZuxbHoZSDZjCLQw==|kb1HGwAk+fQ275ORfLf5Ew==|8UaEYHyqRZcG37JWhYBOBdEatEXd1u1/wN7OuImolcM=",
+			want:      "EncryptMe!",
+		},
+		{
+			name:      "AES-256",
+			key:       "ABEiM0RVZneImaq7zN3u/wARIjNEVWZ3iJmqu8zd7v//7t3Mu6qZiHdmVUQzIhEA/+7dzLuqmYh3ZlVEMyIRAA==",
+			encString: "AAECAwQFBgcICQoLDA0ODw==|4JWf/Tp+BeMX7+Z14ZNrvg==|j7N3wNeCKHhHQZ874XtjOopaVO6bMbN6wU2+5W7qezU=",
+			want:      "jasm-test-value",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rawKey, err := base64.StdEncoding.DecodeString(tt.key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			key := bitwardenKey{encKey: rawKey[:len(rawKey)/2], macKey: rawKey[len(rawKey)/2:]}
+
+			var parts [3][]byte
+			for i, part := range strings.Split(tt.encString, "|") {
+				if parts[i], err = base64.StdEncoding.DecodeString(part); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			got, err := decryptBitwardenCBC(parts[0], parts[1], parts[2], key)
+			if err != nil || string(got) != tt.want {
+				t.Fatalf("decryptBitwardenCBC() = %q, %v; want %q", got, err, tt.want)
+			}
+
+			tag := bytes.Clone(parts[2])
+			tag[len(tag)-1] ^= 1
+			if _, err := decryptBitwardenCBC(parts[0], parts[1], tag, key); err == nil || err.Error() != "MAC mismatch" {
+				t.Errorf("decryptBitwardenCBC() with a forged tag error = %v, want MAC mismatch", err)
+			}
+		})
+	}
+
+	got, err := decryptBitwardenString("2."+tests[1].encString, bitwardenKeyFromBase64(t, tests[1].key))
+	if err != nil || got != tests[1].want {
+		t.Errorf("decryptBitwardenString() = %q, %v; want %q", got, err, tests[1].want)
+	}
+}
+
+// bitwardenKeyFromBase64 decodes a 64-byte encryption and MAC key.
+func bitwardenKeyFromBase64(t *testing.T, encoded string) bitwardenKey {
+	t.Helper()
+	rawKey, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil || len(rawKey) != 64 {
+		t.Fatalf("invalid key %q", encoded)
+	}
+	return bitwardenKey{encKey: rawKey[:32], macKey: rawKey[32:]}
+}
+
+func TestParseBitwardenAccessToken_Invalid(t *testing.T) {
+	for _, token := range []string{
+		"",
+		"not-a-token",
+		"0.client.secret",
+		"1.client.secret:AAAAAAAAAAAAAAAAAAAAAA==",
+		"0.client.secret:dG9vLXNob3J0",
+	} {
+		if _, _, _, err := parseBitwardenAccessToken(token); err == nil {
+			t.Errorf("parseBitwardenAccessToken(%q) expected error", token)
+		}
+	}
+}
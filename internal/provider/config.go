@@ -0,0 +1,260 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ProvidersConfig is the provider configuration file. It declares named
+// provider instances, so the same backend can be registered several times
+// with different settings (e.g. one AWS account per region or role).
+//
+// The file is YAML; JSON documents are accepted as well.
+//
+//	providers:
+//	  - name: aws-prod
+//	    type: aws-secretsmanager
+//	    config:
+//	      region: eu-west-1
+//	      roleArn: arn:aws:iam::111111111111:role/jasm
+//	  - name: vault
+//	    type: vault-kv
+//	    config:
+//	      address: https://vault.example.com:8200
+type ProvidersConfig struct {
+	Providers []ProviderConfig `yaml:"providers"`
+}
+
+// ProviderConfig declares a single provider instance.
+type ProviderConfig struct {
+	// Name is the name the instance is registered under and referenced by in
+	// pod annotations.
+	Name string `yaml:"name"`
+	// Type is the provider implementation (e.g. "aws-secretsmanager", "vault-kv").
+	Type string `yaml:"type"`
+	// Config holds the type-specific settings. Fields that are not set fall
+	// back to the corresponding flag or environment defaults, except for
+	// credentials when the instance sets its own endpoint.
+	Config yaml.Node `yaml:"config"`
+}
+
+// LoadProvidersConfig reads and validates a provider configuration file.
+func LoadProvidersConfig(path string) (*ProvidersConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read provider config %s: %w", path, err)
+	}
+
+	var cfg ProvidersConfig
+	if err := decodeStrict(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse provider config %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool, len(cfg.Providers))
+	for i, p := range cfg.Providers {
+		if p.Name == "" {
+			return nil, fmt.Errorf("provider config %s: entry %d has no name", path, i)
+		}
+		if p.Type == "" {
+			return nil, fmt.Errorf("provider config %s: provider %q has no type", path, p.Name)
+		}
+		if seen[p.Name] {
+			return nil, fmt.Errorf("provider config %s: duplicate provider name %q", path, p.Name)
+		}
+		seen[p.Name] = true
+	}
+
+	return &cfg, nil
+}
+
+// NewProviderFromConfig creates the provider instance declared by cfg.
+// defaults supplies the settings used for fields cfg does not set, as well
+// as shared dependencies such as the Kubernetes client.
+func NewProviderFromConfig(ctx context.Context, cfg ProviderConfig, defaults RegistryOptions) (SecretProvider, error) {
+	switch cfg.Type {
+	case "aws-secretsmanager", "aws-ssm":
+		awsConfig := defaults.AWS
+		// Settings decode into maps in place; keep the defaults intact.
+		awsConfig.NamespaceRoles = maps.Clone(defaults.AWS.NamespaceRoles)
+		if err := decodeProviderConfig(cfg, &awsConfig); err != nil {
+			return nil, err
+		}
+		if awsConfig.Client == nil {
+			awsConfig.Client = defaults.KubeClient
+		}
+		if cfg.Type == "aws-ssm" {
+			return NewAWSSSMParameterStoreProviderWithConfig(ctx, awsConfig)
+		}
+		return NewAWSSecretsManagerProviderWithConfig(ctx, awsConfig)
+
+	case "vault-kv":
+		vaultConfig, ownEndpoint, err := decodeProviderSettings(cfg, defaults.Vault,
+			func(c VaultConfig) string { return c.Address },
+			func(c VaultConfig) VaultConfig {
+				c.Token, c.KubernetesRole, c.KubernetesMountPath, c.ServiceAccountTokenPath = "", "", "", ""
+				return c
+			})
+		if err != nil {
+			return nil, err
+		}
+		if ownEndpoint && vaultConfig.Token == "" && vaultConfig.KubernetesRole == "" {
+			return nil, fmt.Errorf("provider %q sets its own address and must set token or kubernetesRole", cfg.Name)
+		}
+		return NewVaultKVProvider(vaultConfig)
+
+	case "azure-keyvault":
+		azureConfig, _, err := decodeProviderSettings(cfg, defaults.Azure,
+			func(c AzureKeyVaultConfig) string { return c.AuthorityHost + " " + c.VaultDNSSuffix },
+			func(c AzureKeyVaultConfig) AzureKeyVaultConfig {
+				c.ClientSecret, c.FederatedTokenFile = "", ""
+				return c
+			})
+		if err != nil {
+			return nil, err
+		}
+		return NewAzureKeyVaultProvider(azureConfig)
+
+	case "gcp-secretmanager":
+		// The metadata server hands out the controller's own tokens, so an
+		// instance with its own endpoint must name its token source.
+		gcpConfig, ownEndpoint, err := decodeProviderSettings(cfg, defaults.GCP,
+			func(c GCPSecretManagerConfig) string { return c.Endpoint },
+			func(c GCPSecretManagerConfig) GCPSecretManagerConfig {
+				c.MetadataHost = ""
+				return c
+			})
+		if err != nil {
+			return nil, err
+		}
+		if ownEndpoint && gcpConfig.MetadataHost == "" {
+			return nil, fmt.Errorf("provider %q sets its own endpoint and must set metadataHost", cfg.Name)
+		}
+		return NewGCPSecretManagerProvider(gcpConfig)
+
+	case "kubernetes":
+		if err := decodeProviderConfig(cfg, &struct{}{}); err != nil {
+			return nil, err
+		}
+		return NewKubernetesSecretProvider(defaults.KubeClient)
+
+	case "file":
+		fileConfig := struct {
+			Root string `yaml:"root"`
+		}{Root: defaults.FileRoot}
+		if err := decodeProviderConfig(cfg, &fileConfig); err != nil {
+			return nil, err
+		}
+		return NewFileProvider(fileConfig.Root)
+
+	case "sops":
+		sopsConfig := defaults.SOPS
+		if err := decodeProviderConfig(cfg, &sopsConfig); err != nil {
+			return nil, err
+		}
+		if sopsConfig.Client == nil {
+			sopsConfig.Client = defaults.KubeClient
+		}
+		return NewSOPSProvider(sopsConfig)
+
+	case "onepassword":
+		onePasswordConfig, _, err := decodeProviderSettings(cfg, defaults.OnePassword,
+			func(c OnePasswordConfig) string { return c.Host },
+			func(c OnePasswordConfig) OnePasswordConfig {
+				c.Token = ""
+				return c
+			})
+		if err != nil {
+			return nil, err
+		}
+		return NewOnePasswordProvider(onePasswordConfig)
+
+	case "bitwarden":
+		bitwardenConfig, _, err := decodeProviderSettings(cfg, defaults.Bitwarden,
+			func(c BitwardenConfig) string { return c.APIURL + " " + c.IdentityURL },
+			func(c BitwardenConfig) BitwardenConfig {
+				c.AccessToken = ""
+				return c
+			})
+		if err != nil {
+			return nil, err
+		}
+		return NewBitwardenProvider(bitwardenConfig)
+
+	default:
+		return nil, fmt.Errorf("provider %q has unknown type %q", cfg.Name, cfg.Type)
+	}
+}
+
+// providerRegistryFromConfig creates a registry holding the instances
+// declared in the file at opts.ConfigFile.
+func providerRegistryFromConfig(ctx context.Context, opts RegistryOptions) (*ProviderRegistry, error) {
+	cfg, err := LoadProvidersConfig(opts.ConfigFile)
+	if err != nil {
+		return nil, err
+	}
+
+	registry := NewProviderRegistry()
+	for _, providerConfig := range cfg.Providers {
+		secretProvider, err := NewProviderFromConfig(ctx, providerConfig, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create provider %q: %w", providerConfig.Name, err)
+		}
+		registry.RegisterAs(providerConfig.Name, secretProvider)
+	}
+	return registry, nil
+}
+
+// decodeProviderSettings decodes the type-specific settings of cfg over
+// defaults. The default credentials are only inherited by instances using
+// the default endpoint, so that they are never sent to another server: when
+// the settings change the endpoint, they are decoded over
+// withoutCredentials(defaults) instead, and ownEndpoint is set.
+func decodeProviderSettings[T any](cfg ProviderConfig, defaults T, endpoint func(T) string, withoutCredentials func(T) T) (settings T, ownEndpoint bool, err error) {
+	settings = defaults
+	if err := decodeProviderConfig(cfg, &settings); err != nil {
+		return settings, false, err
+	}
+	if endpoint(settings) == endpoint(defaults) {
+		return settings, false, nil
+	}
+
+	settings = withoutCredentials(defaults)
+	if err := decodeProviderConfig(cfg, &settings); err != nil {
+		return settings, true, err
+	}
+	return settings, true, nil
+}
+
+// decodeProviderConfig decodes the type-specific settings of cfg into out,
+// rejecting unknown fields. out is left untouched when cfg has no settings.
+func decodeProviderConfig(cfg ProviderConfig, out interface{}) error {
+	if cfg.Config.IsZero() {
+		return nil
+	}
+	data, err := yaml.Marshal(&cfg.Config)
+	if err != nil {
+		return fmt.Errorf("failed to read config of provider %q: %w", cfg.Name, err)
+	}
+	if err := decodeStrict(data, out); err != nil {
+		return fmt.Errorf("invalid config for provider %q: %w", cfg.Name, err)
+	}
+	return nil
+}
+
+// decodeStrict decodes a YAML (or JSON) document, rejecting unknown fields.
+// An empty document leaves out untouched.
+func decodeStrict(data []byte, out interface{}) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(out); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
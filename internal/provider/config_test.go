@@ -0,0 +1,230 @@
+package provider
+
+import (
+	"context"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestDefaultProviderRegistry_ConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "secrets/app.json"), `{"k":"v"}`)
+	configFile := filepath.Join(dir, "providers.yaml")
+	writeTestFile(t, filepath.Join(dir, "providers.yaml"), `
+providers:
+  - name: aws-prod
+    type: aws-secretsmanager
+    config:
+      region: eu-west-1
+      roleArn: arn:aws:iam::111111111111:role/jasm-a
+  - name: aws-shared
+    type: aws-secretsmanager
+    config:
+      region: us-east-1
+      roleArn: arn:aws:iam::222222222222:role/jasm-b
+      externalId: shared
+  - name: vault
+    type: vault-kv
+    config:
+      address: https://vault.example.com:8200
+  - name: local
+    type: file
+    config:
+      root: `+filepath.Join(dir, "secrets")+`
+`)
+
+	registry, err := DefaultProviderRegistry(context.Background(), RegistryOptions{
+		ConfigFile: configFile,
+		Vault:      VaultConfig{Address: "https://vault.example.com:8200", Token: "default-token"},
+	})
+	if err != nil {
+		t.Fatalf("DefaultProviderRegistry() error = %v", err)
+	}
+
+	names := registry.List()
+	sort.Strings(names)
+	if want := "aws-prod,aws-shared,local,vault"; strings.Join(names, ",") != want {
+		t.Errorf("List() = %v, want %s", names, want)
+	}
+
+	for name, region := range map[string]string{"aws-prod": "eu-west-1", "aws-shared": "us-east-1"} {
+		awsProvider, ok := registry.Get(name).(*AWSSecretsManagerProvider)
+		if !ok {
+			t.Fatalf("Get(%q) = %T, want *AWSSecretsManagerProvider", name, registry.Get(name))
+		}
+		if got := awsProvider.clients.base.Region; got != region {
+			t.Errorf("%s region = %q, want %q", name, got, region)
+		}
+	}
+
+	vaultProvider, ok := registry.Get("vault").(*VaultKVProvider)
+	if !ok {
+		t.Fatalf("Get(vault) = %T, want *VaultKVProvider", registry.Get("vault"))
+	}
+	if vaultProvider.token.clientToken != "default-token" {
+		t.Errorf("vault token = %q, want default from options", vaultProvider.token.clientToken)
+	}
+
+	got, err := registry.Get("local").FetchSecret(context.Background(), "app.json")
+	if err != nil || got["k"] != "v" {
+		t.Errorf("FetchSecret() = %v, %v", got, err)
+	}
+	if registry.Get("aws-secretsmanager") != nil {
+		t.Error("built-in providers should not be registered when a config file is used")
+	}
+}
+
+func TestLoadProvidersConfig_JSON(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "providers.json"), `{"providers": [{"name": "op", "type": "onepassword", "config": {"host": "http://connect:8080", "token": "t"}}]}`)
+
+	cfg, err := LoadProvidersConfig(filepath.Join(dir, "providers.json"))
+	if err != nil {
+		t.Fatalf("LoadProvidersConfig() error = %v", err)
+	}
+	secretProvider, err := NewProviderFromConfig(context.Background(), cfg.Providers[0], RegistryOptions{})
+	if err != nil {
+		t.Fatalf("NewProviderFromConfig() error = %v", err)
+	}
+	if secretProvider.Name() != "onepassword" {
+		t.Errorf("Name() = %q, want onepassword", secretProvider.Name())
+	}
+}
+
+func TestLoadProvidersConfig_Invalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{
+			name:    "Missing name",
+			content: "providers:\n  - type: vault-kv\n",
+			wantErr: "has no name",
+		},
+		{
+			name:    "Missing type",
+			content: "providers:\n  - name: vault\n",
+			wantErr: "has no type",
+		},
+		{
+			name:    "Duplicate name",
+			content: "providers:\n  - {name: a, type: file}\n  - {name: a, type: vault-kv}\n",
+			wantErr: "duplicate provider name",
+		},
+		{
+			name:    "Unknown field",
+			content: "provider:\n  - {name: a, type: file}\n",
+			wantErr: "not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTestFile(t, filepath.Join(dir, "providers.yaml"), tt.content)
+			_, err := LoadProvidersConfig(filepath.Join(dir, "providers.yaml"))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("LoadProvidersConfig() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNewProviderFromConfig_Invalid(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "providers.yaml"), `
+providers:
+  - name: unknown
+    type: keepass
+  - name: typo
+    type: vault-kv
+    config:
+      adress: https://vault.example.com:8200
+`)
+	cfg, err := LoadProvidersConfig(filepath.Join(dir, "providers.yaml"))
+	if err != nil {
+		t.Fatalf("LoadProvidersConfig() error = %v", err)
+	}
+	for _, providerConfig := range cfg.Providers {
+		if _, err := NewProviderFromConfig(context.Background(), providerConfig, RegistryOptions{}); err == nil {
+			t.Errorf("NewProviderFromConfig(%q) expected error", providerConfig.Name)
+		}
+	}
+}
+
+func TestNewProviderFromConfig_OwnEndpointCredentials(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFile(t, filepath.Join(dir, "providers.yaml"), `
+providers:
+  - name: vault-other
+    type: vault-kv
+    config:
+      address: https://vault.attacker.example:8200
+  - name: vault-own-token
+    type: vault-kv
+    config:
+      address: https://vault.team.example:8200
+      token: team-token
+  - name: op-other
+    type: onepassword
+    config:
+      host: http://connect.other:8080
+  - name: bitwarden-other
+    type: bitwarden
+    config:
+      apiUrl: https://bitwarden.other/api
+  - name: azure-other
+    type: azure-keyvault
+    config:
+      authorityHost: https://login.other.example/
+  - name: gcp-other
+    type: gcp-secretmanager
+    config:
+      endpoint: https://secretmanager.attacker.example
+  - name: gcp-own-metadata
+    type: gcp-secretmanager
+    config:
+      endpoint: https://secretmanager.team.example
+      metadataHost: token-broker.team.example
+`)
+	cfg, err := LoadProvidersConfig(filepath.Join(dir, "providers.yaml"))
+	if err != nil {
+		t.Fatalf("LoadProvidersConfig() error = %v", err)
+	}
+	defaults := RegistryOptions{
+		Vault:       VaultConfig{Address: "https://vault.example.com:8200", Token: "default-token", KubernetesRole: "jasm"},
+		OnePassword: OnePasswordConfig{Host: "http://connect:8080", Token: "default-token"},
+		Bitwarden:   BitwardenConfig{AccessToken: "0.client.secret:AAAAAAAAAAAAAAAAAAAAAA=="},
+		Azure:       AzureKeyVaultConfig{TenantID: "tenant", ClientID: "client", ClientSecret: "default-secret"},
+		GCP:         GCPSecretManagerConfig{Enabled: true, MetadataHost: "metadata.google.internal"},
+	}
+
+	for _, providerConfig := range cfg.Providers {
+		secretProvider, err := NewProviderFromConfig(context.Background(), providerConfig, defaults)
+		if providerConfig.Name == "vault-own-token" {
+			if err != nil {
+				t.Fatalf("NewProviderFromConfig(%q) error = %v", providerConfig.Name, err)
+			}
+			vaultProvider := secretProvider.(*VaultKVProvider)
+			if vaultProvider.token.clientToken != "team-token" || vaultProvider.auth != nil {
+				t.Errorf("vault-own-token token = %q, auth = %v; want its own token only", vaultProvider.token.clientToken, vaultProvider.auth)
+			}
+			continue
+		}
+		if providerConfig.Name == "gcp-own-metadata" {
+			if err != nil {
+				t.Fatalf("NewProviderFromConfig(%q) error = %v", providerConfig.Name, err)
+			}
+			if got := secretProvider.(*GCPSecretManagerProvider).metadataHost; got != "token-broker.team.example" {
+				t.Errorf("gcp-own-metadata metadataHost = %q, want its own", got)
+			}
+			continue
+		}
+		if err == nil {
+			t.Errorf("NewProviderFromConfig(%q) expected an error for missing credentials", providerConfig.Name)
+		}
+	}
+}
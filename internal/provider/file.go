@@ -0,0 +1,185 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileProvider implements SecretProvider for files on a volume mounted into
+// the controller, for clusters without access to a cloud secret manager.
+//
+// Paths are relative to the provider's root directory and cannot escape it.
+// The format is chosen by extension:
+//   - .json: a JSON object
+//   - .yaml, .yml: a YAML mapping
+//   - .env: dotenv KEY=VALUE lines
+//   - a directory: one key per regular file, named after the file
+//   - anything else: the raw file content under the file name
+type FileProvider struct {
+	root string
+}
+
+// NewFileProvider creates a new file provider serving files below root.
+func NewFileProvider(root string) (*FileProvider, error) {
+	info, err := os.Stat(root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access file provider root: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("file provider root %s is not a directory", root)
+	}
+	return &FileProvider{root: root}, nil
+}
+
+// Name returns the provider identifier.
+func (p *FileProvider) Name() string {
+	return "file"
+}
+
+// FetchSecret reads the file or directory at path below the provider root.
+func (p *FileProvider) FetchSecret(_ context.Context, path string) (map[string]string, error) {
+	name := filepath.Clean(strings.TrimPrefix(path, "/"))
+
+	// os.Root rejects paths (including symlinks) resolving outside the root.
+	root, err := os.OpenRoot(p.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file provider root: %w", err)
+	}
+	defer root.Close()
+
+	info, err := root.Stat(name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access %s: %w", path, err)
+	}
+
+	if info.IsDir() {
+		return readSecretDir(root, name)
+	}
+
+	content, err := readRootFile(root, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return parseSecretFile(filepath.Base(name), content)
+}
+
+// readSecretDir reads every regular file in dir as one key. Hidden entries are
+// skipped, which also skips the "..data" bookkeeping of Secret and ConfigMap volumes.
+func readSecretDir(root *os.Root, dir string) (map[string]string, error) {
+	entries, err := fs.ReadDir(root.FS(), dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", dir, err)
+	}
+
+	secretData := make(map[string]string, len(entries))
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		entryPath := filepath.Join(dir, entry.Name())
+		info, err := root.Stat(entryPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to access %s: %w", entryPath, err)
+		}
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		content, err := readRootFile(root, entryPath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entryPath, err)
+		}
+		secretData[entry.Name()] = string(content)
+	}
+
+	if len(secretData) == 0 {
+		return nil, fmt.Errorf("directory %s contains no files", dir)
+	}
+	return secretData, nil
+}
+
+// readRootFile reads the named file below root.
+func readRootFile(root *os.Root, name string) ([]byte, error) {
+	f, err := root.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return io.ReadAll(f)
+}
+
+// parseSecretFile decodes content according to the extension of fileName.
+func parseSecretFile(fileName string, content []byte) (map[string]string, error) {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".json":
+		var rawData map[string]interface{}
+		if err := json.Unmarshal(content, &rawData); err != nil {
+			return nil, fmt.Errorf("failed to parse %s as JSON: %w", fileName, err)
+		}
+		return stringifySecretData(rawData), nil
+	case ".yaml", ".yml":
+		var rawData map[string]interface{}
+		if err := yaml.Unmarshal(content, &rawData); err != nil {
+			return nil, fmt.Errorf("failed to parse %s as YAML: %w", fileName, err)
+		}
+		return stringifySecretData(rawData), nil
+	case ".env":
+		secretData, err := parseDotenv(content)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s as dotenv: %w", fileName, err)
+		}
+		return secretData, nil
+	default:
+		return map[string]string{fileName: string(content)}, nil
+	}
+}
+
+// parseDotenv parses KEY=VALUE lines. Blank lines and "#" comments are
+// ignored, an optional "export " prefix is allowed, and double-quoted values
+// are unquoted with Go escape rules while single-quoted values are taken literally.
+func parseDotenv(content []byte) (map[string]string, error) {
+	secretData := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, found := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !found || key == "" {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", lineNumber)
+		}
+		value = strings.TrimSpace(value)
+
+		switch {
+		case len(value) >= 2 && value[0] == '"' && value[len(value)-1] == '"':
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: invalid quoted value: %w", lineNumber, err)
+			}
+			value = unquoted
+		case len(value) >= 2 && value[0] == '\'' && value[len(value)-1] == '\'':
+			value = value[1 : len(value)-1]
+		}
+		secretData[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return secretData, nil
+}
@@ -0,0 +1,123 @@
+package provider
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestFileProvider_Name(t *testing.T) {
+	provider := &FileProvider{}
+	if got := provider.Name(); got != "file" {
+		t.Errorf("Name() = %v, want %v", got, "file")
+	}
+}
+
+func TestFileProvider_FetchSecret(t *testing.T) {
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "prod/db.json"), `{"username":"admin","port":5432}`)
+	writeTestFile(t, filepath.Join(root, "prod/app.yaml"), "api_key: abc123\nenabled: true\nlimits:\n  cpu: 2\n")
+	writeTestFile(t, filepath.Join(root, "prod/app.env"), "# comment\nexport DB_HOST=db.local\nDB_PASS=\"p@ss\\nword\"\nRAW='$literal'\n\n")
+	writeTestFile(t, filepath.Join(root, "prod/tls/tls.crt"), "CERT")
+	writeTestFile(t, filepath.Join(root, "prod/tls/tls.key"), "KEY")
+	writeTestFile(t, filepath.Join(root, "prod/tls/.hidden"), "ignored")
+	writeTestFile(t, filepath.Join(root, "prod/ca.pem"), "PEM")
+	writeTestFile(t, filepath.Join(t.TempDir(), "outside.json"), `{"leak":"yes"}`)
+
+	provider, err := NewFileProvider(root)
+	if err != nil {
+		t.Fatalf("NewFileProvider() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "JSON file",
+			path: "prod/db.json",
+			want: map[string]string{"username": "admin", "port": "5432"},
+		},
+		{
+			name: "YAML file",
+			path: "/prod/app.yaml",
+			want: map[string]string{"api_key": "abc123", "enabled": "true", "limits": `{"cpu":2}`},
+		},
+		{
+			name: "Dotenv file",
+			path: "prod/app.env",
+			want: map[string]string{"DB_HOST": "db.local", "DB_PASS": "p@ss\nword", "RAW": "$literal"},
+		},
+		{
+			name: "Directory of files",
+			path: "prod/tls",
+			want: map[string]string{"tls.crt": "CERT", "tls.key": "KEY"},
+		},
+		{
+			name: "Raw file",
+			path: "prod/ca.pem",
+			want: map[string]string{"ca.pem": "PEM"},
+		},
+		{
+			name:    "Missing file",
+			path:    "prod/missing.json",
+			wantErr: true,
+		},
+		{
+			name:    "Path escaping root",
+			path:    "../outside.json",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.FetchSecret(context.Background(), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d keys, want %d keys: %v", len(got), len(tt.want), got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("key %q: got %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDotenv_Invalid(t *testing.T) {
+	if _, err := parseDotenv([]byte("NOT_A_PAIR\n")); err == nil {
+		t.Error("parseDotenv() expected error for line without '='")
+	}
+}
+
+func TestNewFileProvider_RequiresDirectory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "file")
+	writeTestFile(t, file, "x")
+
+	if _, err := NewFileProvider(file); err == nil {
+		t.Error("NewFileProvider() expected error for non-directory root")
+	}
+	if _, err := NewFileProvider(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("NewFileProvider() expected error for missing root")
+	}
+}
@@ -0,0 +1,199 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultGCPSecretManagerEndpoint is the Secret Manager REST endpoint.
+	defaultGCPSecretManagerEndpoint = "https://secretmanager.googleapis.com"
+	// defaultGCPMetadataHost is the GCE/GKE metadata server host.
+	defaultGCPMetadataHost = "metadata.google.internal"
+	// gcpTokenExpiryMargin is the tokenExpiry margin of metadata server
+	// tokens.
+	gcpTokenExpiryMargin = time.Minute
+)
+
+// GCPSecretManagerConfig holds the settings for the GCP Secret Manager provider.
+type GCPSecretManagerConfig struct {
+	// Enabled registers the provider in DefaultProviderRegistry.
+	Enabled bool `yaml:"-"`
+	// Endpoint is the Secret Manager REST endpoint. Defaults to the global endpoint.
+	Endpoint string `yaml:"endpoint"`
+	// MetadataHost is the metadata server used to obtain access tokens.
+	// Defaults to GCE_METADATA_HOST or metadata.google.internal.
+	MetadataHost string `yaml:"metadataHost"`
+	// HTTPClient sends the metadata server and Secret Manager requests
+	// (optional).
+	HTTPClient *http.Client `yaml:"-"`
+}
+
+// GCPSecretManagerProvider implements SecretProvider for Google Cloud Secret Manager.
+// Paths have the form "projects/<project>/secrets/<secret>/versions/<version|latest>";
+// the "/versions/..." suffix may be omitted to read the latest version.
+//
+// Access tokens are obtained from the metadata server, which on GKE serves
+// the Workload Identity of the controller's ServiceAccount.
+type GCPSecretManagerProvider struct {
+	endpoint     string
+	metadataHost string
+	httpClient   *http.Client
+
+	mu          sync.Mutex
+	accessToken string
+	expiresAt   time.Time
+}
+
+// NewGCPSecretManagerProvider creates a new GCP Secret Manager provider.
+func NewGCPSecretManagerProvider(cfg GCPSecretManagerConfig) (*GCPSecretManagerProvider, error) {
+	endpoint := cfg.Endpoint
+	if endpoint == "" {
+		endpoint = defaultGCPSecretManagerEndpoint
+	}
+
+	metadataHost := cfg.MetadataHost
+	if metadataHost == "" {
+		metadataHost = os.Getenv("GCE_METADATA_HOST")
+	}
+	if metadataHost == "" {
+		metadataHost = defaultGCPMetadataHost
+	}
+
+	return &GCPSecretManagerProvider{
+		endpoint:     strings.TrimRight(endpoint, "/"),
+		metadataHost: metadataHost,
+		httpClient:   httpClientOrDefault(cfg.HTTPClient),
+	}, nil
+}
+
+// Name returns the provider identifier.
+func (p *GCPSecretManagerProvider) Name() string {
+	return "gcp-secretmanager"
+}
+
+// FetchSecret accesses a secret version in GCP Secret Manager.
+// A payload holding a JSON object is expanded into its keys; any other
+// payload is returned under the secret name.
+func (p *GCPSecretManagerProvider) FetchSecret(ctx context.Context, path string) (map[string]string, error) {
+	versionName, secretID, err := parseGCPSecretPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := p.token(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.endpoint+"/v1/"+versionName+":access", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Secret Manager request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var response struct {
+		Payload *struct {
+			Data       string `json:"data"`
+			DataCrc32c string `json:"dataCrc32c"`
+		} `json:"payload"`
+	}
+	if err := doJSON(p.httpClient, req, &response, gcpErrorMessage); err != nil {
+		return nil, fmt.Errorf("failed to fetch secret from GCP Secret Manager: %w", err)
+	}
+	if response.Payload == nil {
+		return nil, fmt.Errorf("secret %s does not contain a payload", path)
+	}
+
+	payload, err := base64.StdEncoding.DecodeString(response.Payload.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode secret payload: %w", err)
+	}
+	if response.Payload.DataCrc32c != "" {
+		want, err := strconv.ParseUint(response.Payload.DataCrc32c, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload checksum %q: %w", response.Payload.DataCrc32c, err)
+		}
+		if got := crc32.Checksum(payload, crc32.MakeTable(crc32.Castagnoli)); uint64(got) != want {
+			return nil, fmt.Errorf("secret %s payload checksum mismatch", path)
+		}
+	}
+
+	return parseSecretPayload(string(payload), secretID), nil
+}
+
+// parseGCPSecretPath validates path and returns the full secret version
+// resource name along with the secret ID.
+func parseGCPSecretPath(path string) (versionName, secretID string, err error) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	valid := (len(parts) == 4 || len(parts) == 6) &&
+		parts[0] == "projects" && parts[1] != "" &&
+		parts[2] == "secrets" && parts[3] != ""
+	if valid && len(parts) == 6 {
+		valid = parts[4] == "versions" && parts[5] != ""
+	}
+	if !valid {
+		return "", "", fmt.Errorf("invalid GCP secret path %q: expected projects/<project>/secrets/<secret>[/versions/<version|latest>]", path)
+	}
+
+	if len(parts) == 4 {
+		parts = append(parts, "versions", "latest")
+	}
+	return strings.Join(parts, "/"), parts[3], nil
+}
+
+// token returns a cached access token, requesting a new one from the
+// metadata server when it is missing or about to expire.
+func (p *GCPSecretManagerProvider) token(ctx context.Context) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.accessToken != "" && time.Now().Before(p.expiresAt) {
+		return p.accessToken, nil
+	}
+
+	tokenURL := "http://" + p.metadataHost + "/computeMetadata/v1/instance/service-accounts/default/token"
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL, nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to create metadata token request: %w", err)
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := doJSON(p.httpClient, req, &response, gcpErrorMessage); err != nil {
+		return "", fmt.Errorf("failed to acquire GCP access token from metadata server: %w", err)
+	}
+	if response.AccessToken == "" {
+		return "", fmt.Errorf("metadata server response did not contain an access token")
+	}
+
+	p.accessToken = response.AccessToken
+	p.expiresAt = tokenExpiry(response.ExpiresIn, gcpTokenExpiryMargin)
+	return p.accessToken, nil
+}
+
+// gcpErrorMessage extracts the message of a Google API error response.
+func gcpErrorMessage(body []byte) string {
+	var errResponse struct {
+		Error struct {
+			Status  string `json:"status"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if json.Unmarshal(body, &errResponse) != nil || errResponse.Error.Message == "" {
+		return ""
+	}
+	return errResponse.Error.Status + ": " + errResponse.Error.Message
+}
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newFakeGCP starts an httptest server that mimics both the metadata server
+// token endpoint and the Secret Manager access endpoint.
+func newFakeGCP(t *testing.T, secrets map[string]string) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/computeMetadata/v1/instance/service-accounts/default/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Write([]byte(`{"access_token":"gcp-token","expires_in":3599,"token_type":"Bearer"}`))
+	})
+	mux.HandleFunc("/v1/projects/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer gcp-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/v1/"), ":access")
+		payload, ok := secrets[name]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"error":{"code":404,"status":"NOT_FOUND","message":"Secret not found"}}`))
+			return
+		}
+		checksum := crc32.Checksum([]byte(payload), crc32.MakeTable(crc32.Castagnoli))
+		fmt.Fprintf(w, `{"name":%q,"payload":{"data":%q,"dataCrc32c":"%d"}}`,
+			name, base64.StdEncoding.EncodeToString([]byte(payload)), checksum)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGCPSecretManagerProvider_Name(t *testing.T) {
+	provider := &GCPSecretManagerProvider{}
+	if got := provider.Name(); got != "gcp-secretmanager" {
+		t.Errorf("Name() = %v, want %v", got, "gcp-secretmanager")
+	}
+}
+
+func TestGCPSecretManagerProvider_FetchSecret(t *testing.T) {
+	server := newFakeGCP(t, map[string]string{
+		"projects/acme/secrets/db-creds/versions/latest": `{"username":"admin","port":5432,"ssl":true}`,
+		"projects/acme/secrets/api-token/versions/3":     "plain-token",
+	})
+
+	provider, err := NewGCPSecretManagerProvider(GCPSecretManagerConfig{
+		Endpoint:     server.URL,
+		MetadataHost: strings.TrimPrefix(server.URL, "http://"),
+	})
+	if err != nil {
+		t.Fatalf("NewGCPSecretManagerProvider() error = %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		path    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name: "JSON payload latest version",
+			path: "projects/acme/secrets/db-creds/versions/latest",
+			want: map[string]string{"username": "admin", "port": "5432", "ssl": "true"},
+		},
+		{
+			name: "Implicit latest version",
+			path: "projects/acme/secrets/db-creds",
+			want: map[string]string{"username": "admin", "port": "5432", "ssl": "true"},
+		},
+		{
+			name: "Non-JSON payload pinned version",
+			path: "projects/acme/secrets/api-token/versions/3",
+			want: map[string]string{"api-token": "plain-token"},
+		},
+		{
+			name:    "Missing secret",
+			path:    "projects/acme/secrets/missing",
+			wantErr: true,
+		},
+		{
+			name:    "Malformed path",
+			path:    "acme/db-creds",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := provider.FetchSecret(context.Background(), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d keys, want %d keys", len(got), len(tt.want))
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("key %q: got %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestParseSecretPayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    map[string]string
+	}{
+		{
+			name:    "JSON object",
+			payload: `{"user":"admin","nested":{"a":1}}`,
+			want:    map[string]string{"user": "admin", "nested": `{"a":1}`},
+		},
+		{
+			name:    "Plain string",
+			payload: "hunter2",
+			want:    map[string]string{"value": "hunter2"},
+		},
+		{
+			name:    "JSON array is not an object",
+			payload: `["a","b"]`,
+			want:    map[string]string{"value": `["a","b"]`},
+		},
+		{
+			name:    "JSON null",
+			payload: "null",
+			want:    map[string]string{"value": "null"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseSecretPayload(tt.payload, "value")
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d keys, want %d keys", len(got), len(tt.want))
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("key %q: got %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const (
+	// defaultHTTPTimeout bounds every request of the HTTP API providers
+	// unless their config supplies its own HTTPClient.
+	defaultHTTPTimeout = 30 * time.Second
+	// maxErrorBodySize caps how much of an error response is read, so that
+	// a misbehaving endpoint cannot make the controller buffer large bodies.
+	maxErrorBodySize = 1 << 20
+)
+
+// httpClientOrDefault returns client, or a client with defaultHTTPTimeout
+// when client is nil.
+func httpClientOrDefault(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return &http.Client{Timeout: defaultHTTPTimeout}
+}
+
+// httpStatusError is returned by doJSON when an API responds with a non-2xx
+// status.
+type httpStatusError struct {
+	StatusCode int
+	// Message is the error reported in the response body, if any.
+	Message string
+}
+
+func (e *httpStatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("status %d: %s", e.StatusCode, e.Message)
+}
+
+// isHTTPStatus reports whether err wraps an httpStatusError with the given
+// status code.
+func isHTTPStatus(err error, statusCode int) bool {
+	var statusErr *httpStatusError
+	return errors.As(err, &statusErr) && statusErr.StatusCode == statusCode
+}
+
+// newJSONRequest creates a request whose body, if not nil, is encoded as JSON.
+func newJSONRequest(ctx context.Context, method, url string, body interface{}) (*http.Request, error) {
+	var reqBody io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request: %w", err)
+		}
+		reqBody = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+// doJSON sends req with client and decodes a successful JSON response into
+// out. Other responses are returned as an httpStatusError whose message is
+// extracted from the first maxErrorBodySize bytes of the body by
+// errorMessage, which may be nil.
+func doJSON(client *http.Client, req *http.Request, out interface{}, errorMessage func(body []byte) string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		statusErr := &httpStatusError{StatusCode: resp.StatusCode}
+		body, err := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		if err == nil && errorMessage != nil {
+			statusErr.Message = errorMessage(body)
+		}
+		return statusErr
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to parse response: %w", err)
+	}
+	return nil
+}
+
+// tokenExpiry returns when a token valid for expiresIn seconds should be
+// replaced: margin before it actually expires, so that no request is sent
+// with a token about to lapse.
+func tokenExpiry(expiresIn int, margin time.Duration) time.Time {
+	return time.Now().Add(time.Duration(expiresIn)*time.Second - margin)
+}
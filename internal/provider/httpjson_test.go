@@ -0,0 +1,62 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDoJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte(`{"value":"s3cret"}`))
+		case "/denied":
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"message":"permission denied"}`))
+		case "/huge":
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(strings.Repeat("x", 2*maxErrorBodySize)))
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	send := func(path string, errorMessage func([]byte) string) (string, error) {
+		req, err := newJSONRequest(context.Background(), http.MethodGet, server.URL+path, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var response struct {
+			Value string `json:"value"`
+		}
+		err = doJSON(httpClientOrDefault(nil), req, &response, errorMessage)
+		return response.Value, err
+	}
+
+	if got, err := send("/ok", nil); err != nil || got != "s3cret" {
+		t.Errorf("doJSON() = %q, %v, want s3cret", got, err)
+	}
+
+	_, err := send("/denied", onePasswordErrorMessage)
+	if !isHTTPStatus(fmt.Errorf("wrapped: %w", err), http.StatusForbidden) {
+		t.Errorf("doJSON() error = %v, want a status 403 error", err)
+	}
+	if err == nil || err.Error() != "status 403: permission denied" {
+		t.Errorf("doJSON() error = %v, want the error message from the body", err)
+	}
+
+	var bodySize int
+	_, err = send("/huge", func(body []byte) string {
+		bodySize = len(body)
+		return ""
+	})
+	if !isHTTPStatus(err, http.StatusInternalServerError) {
+		t.Errorf("doJSON() error = %v, want a status 500 error", err)
+	}
+	if bodySize != maxErrorBodySize {
+		t.Errorf("error body size = %d, want %d", bodySize, maxErrorBodySize)
+	}
+}
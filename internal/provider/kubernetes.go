@@ -0,0 +1,113 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// KubernetesAllowedNamespacesAnnotation lists, comma-separated, the namespaces
+	// allowed to pull a source Secret through the kubernetes provider.
+	// Entries may be glob patterns (e.g. "tenant-*"); "*" allows every namespace.
+	KubernetesAllowedNamespacesAnnotation = "jasm.codnod.io/allowed-namespaces"
+)
+
+// KubernetesSecretProvider implements SecretProvider for Secrets stored in
+// another namespace of the same cluster. Paths have the form "namespace/name".
+//
+// A source Secret is only readable by pods in its own namespace or in a
+// namespace listed in its KubernetesAllowedNamespacesAnnotation.
+type KubernetesSecretProvider struct {
+	client client.Reader
+}
+
+// NewKubernetesSecretProvider creates a new Kubernetes Secret provider.
+func NewKubernetesSecretProvider(reader client.Reader) (*KubernetesSecretProvider, error) {
+	if reader == nil {
+		return nil, fmt.Errorf("kubernetes client is required")
+	}
+	return &KubernetesSecretProvider{client: reader}, nil
+}
+
+// Name returns the provider identifier.
+func (p *KubernetesSecretProvider) Name() string {
+	return "kubernetes"
+}
+
+// FetchSecret reads the source Secret after checking that the requesting
+// namespace (see WithRequestNamespace) is allowed to pull it.
+func (p *KubernetesSecretProvider) FetchSecret(ctx context.Context, secretPath string) (map[string]string, error) {
+	secretValue, err := p.FetchSecretValue(ctx, secretPath)
+	if err != nil {
+		return nil, err
+	}
+	return stringSecretData(secretValue.Data), nil
+}
+
+// FetchSecretValue reads the source Secret like FetchSecret, along with its
+// type.
+func (p *KubernetesSecretProvider) FetchSecretValue(ctx context.Context, secretPath string) (*SecretValue, error) {
+	namespace, name, err := ParseKubernetesSecretPath(secretPath)
+	if err != nil {
+		return nil, err
+	}
+
+	requestNamespace := RequestNamespace(ctx)
+	if requestNamespace == "" {
+		return nil, fmt.Errorf("requesting namespace is unknown")
+	}
+
+	var secret corev1.Secret
+	if err := p.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &secret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("secret %s not found", secretPath)
+		}
+		return nil, fmt.Errorf("failed to get secret %s: %w", secretPath, err)
+	}
+
+	if !namespaceAllowed(&secret, requestNamespace) {
+		return nil, fmt.Errorf("namespace %s is not allowed to read secret %s (see %s annotation)",
+			requestNamespace, secretPath, KubernetesAllowedNamespacesAnnotation)
+	}
+
+	secretData := make(map[string][]byte, len(secret.Data))
+	for k, v := range secret.Data {
+		secretData[k] = v
+	}
+	return &SecretValue{Data: secretData, Type: secret.Type}, nil
+}
+
+// ParseKubernetesSecretPath splits a kubernetes provider path of the form
+// "namespace/name".
+func ParseKubernetesSecretPath(secretPath string) (namespace, name string, err error) {
+	parts := strings.Split(strings.Trim(secretPath, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid kubernetes secret path %q: expected namespace/name", secretPath)
+	}
+	return parts[0], parts[1], nil
+}
+
+// namespaceAllowed reports whether namespace may read the source object.
+func namespaceAllowed(source metav1.Object, namespace string) bool {
+	if source.GetNamespace() == namespace {
+		return true
+	}
+
+	for _, pattern := range strings.Split(source.GetAnnotations()[KubernetesAllowedNamespacesAnnotation], ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if matched, err := path.Match(pattern, namespace); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
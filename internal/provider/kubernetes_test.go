@@ -0,0 +1,146 @@
+package provider
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestKubernetesSecretProvider_Name(t *testing.T) {
+	provider := &KubernetesSecretProvider{}
+	if got := provider.Name(); got != "kubernetes" {
+		t.Errorf("Name() = %v, want %v", got, "kubernetes")
+	}
+}
+
+func TestKubernetesSecretProvider_FetchSecret(t *testing.T) {
+	reader := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "shared-db",
+				Namespace: "platform-secrets",
+				Annotations: map[string]string{
+					KubernetesAllowedNamespacesAnnotation: "tenant-a, team-*",
+				},
+			},
+			Data: map[string][]byte{"username": []byte("admin"), "password": []byte("s3cret")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "private", Namespace: "platform-secrets"},
+			Data:       map[string][]byte{"token": []byte("t")},
+		},
+	).Build()
+
+	provider, err := NewKubernetesSecretProvider(reader)
+	if err != nil {
+		t.Fatalf("NewKubernetesSecretProvider() error = %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		path      string
+		want      map[string]string
+		wantErr   bool
+	}{
+		{
+			name:      "Allowed namespace",
+			namespace: "tenant-a",
+			path:      "platform-secrets/shared-db",
+			want:      map[string]string{"username": "admin", "password": "s3cret"},
+		},
+		{
+			name:      "Allowed by glob pattern",
+			namespace: "team-payments",
+			path:      "platform-secrets/shared-db",
+			want:      map[string]string{"username": "admin", "password": "s3cret"},
+		},
+		{
+			name:      "Same namespace always allowed",
+			namespace: "platform-secrets",
+			path:      "platform-secrets/private",
+			want:      map[string]string{"token": "t"},
+		},
+		{
+			name:      "Namespace not in allowlist",
+			namespace: "tenant-b",
+			path:      "platform-secrets/shared-db",
+			wantErr:   true,
+		},
+		{
+			name:      "Secret without allowlist",
+			namespace: "tenant-a",
+			path:      "platform-secrets/private",
+			wantErr:   true,
+		},
+		{
+			name:      "Missing secret",
+			namespace: "tenant-a",
+			path:      "platform-secrets/missing",
+			wantErr:   true,
+		},
+		{
+			name:      "Malformed path",
+			namespace: "tenant-a",
+			path:      "shared-db",
+			wantErr:   true,
+		},
+		{
+			name:    "Unknown requesting namespace",
+			path:    "platform-secrets/shared-db",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.namespace != "" {
+				ctx = WithRequestNamespace(ctx, tt.namespace)
+			}
+
+			got, err := provider.FetchSecret(ctx, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d keys, want %d keys", len(got), len(tt.want))
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("key %q: got %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestKubernetesSecretProvider_FetchSecretValue(t *testing.T) {
+	reader := fake.NewClientBuilder().WithObjects(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "wildcard-tls", Namespace: "platform"},
+		Type:       corev1.SecretTypeTLS,
+		Data:       map[string][]byte{corev1.TLSCertKey: []byte("CRT"), corev1.TLSPrivateKeyKey: {0xff, 0x00}},
+	}).Build()
+
+	provider, err := NewKubernetesSecretProvider(reader)
+	if err != nil {
+		t.Fatalf("NewKubernetesSecretProvider() error = %v", err)
+	}
+
+	got, err := provider.FetchSecretValue(WithRequestNamespace(context.Background(), "platform"), "platform/wildcard-tls")
+	if err != nil {
+		t.Fatalf("FetchSecretValue() error = %v", err)
+	}
+	if got.Type != corev1.SecretTypeTLS {
+		t.Errorf("Type = %q, want %q", got.Type, corev1.SecretTypeTLS)
+	}
+	if string(got.Data[corev1.TLSPrivateKeyKey]) != "\xff\x00" {
+		t.Errorf("Data[%s] = %q, want binary value", corev1.TLSPrivateKeyKey, got.Data[corev1.TLSPrivateKeyKey])
+	}
+}
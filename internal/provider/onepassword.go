@@ -0,0 +1,189 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// OnePasswordConfig holds the settings for a 1Password Connect server.
+type OnePasswordConfig struct {
+	// Host is the Connect server URL (e.g., "http://onepassword-connect:8080").
+	Host string `yaml:"host"`
+	// Token is the Connect access token.
+	Token string `yaml:"token"`
+	// HTTPClient sends the Connect API requests (optional).
+	HTTPClient *http.Client `yaml:"-"`
+}
+
+// OnePasswordConfigFromEnv builds a OnePasswordConfig from the standard
+// Connect environment variables OP_CONNECT_HOST and OP_CONNECT_TOKEN.
+func OnePasswordConfigFromEnv() OnePasswordConfig {
+	return OnePasswordConfig{
+		Host:  os.Getenv("OP_CONNECT_HOST"),
+		Token: os.Getenv("OP_CONNECT_TOKEN"),
+	}
+}
+
+// OnePasswordProvider implements SecretProvider for 1Password Connect.
+// Paths have the form "vault/item", where each part is a name or an ID.
+//
+// Item fields are flattened into keys named after their labels. When the same
+// label appears in several sections, the section label is prefixed
+// ("section.label") to keep keys unique.
+type OnePasswordProvider struct {
+	host       string
+	token      string
+	httpClient *http.Client
+}
+
+// onePasswordItem is the subset of a Connect item used by the provider.
+type onePasswordItem struct {
+	ID       string `json:"id"`
+	Title    string `json:"title"`
+	Sections []struct {
+		ID    string `json:"id"`
+		Label string `json:"label"`
+	} `json:"sections"`
+	Fields []struct {
+		ID      string `json:"id"`
+		Label   string `json:"label"`
+		Value   string `json:"value"`
+		Section *struct {
+			ID string `json:"id"`
+		} `json:"section"`
+	} `json:"fields"`
+}
+
+// NewOnePasswordProvider creates a new 1Password Connect provider.
+func NewOnePasswordProvider(cfg OnePasswordConfig) (*OnePasswordProvider, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("1password connect host is required")
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("1password connect token is required")
+	}
+
+	return &OnePasswordProvider{
+		host:       strings.TrimRight(cfg.Host, "/"),
+		token:      cfg.Token,
+		httpClient: httpClientOrDefault(cfg.HTTPClient),
+	}, nil
+}
+
+// Name returns the provider identifier.
+func (p *OnePasswordProvider) Name() string {
+	return "onepassword"
+}
+
+// FetchSecret resolves the vault and item and returns the item's fields.
+func (p *OnePasswordProvider) FetchSecret(ctx context.Context, path string) (map[string]string, error) {
+	vaultRef, itemRef, found := strings.Cut(strings.Trim(path, "/"), "/")
+	if !found || vaultRef == "" || itemRef == "" || strings.Contains(itemRef, "/") {
+		return nil, fmt.Errorf("invalid 1Password path %q: expected vault/item", path)
+	}
+
+	vaultID, err := p.resolveID(ctx, "/v1/vaults", "name", vaultRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve 1Password vault %s: %w", vaultRef, err)
+	}
+
+	itemsPath := "/v1/vaults/" + url.PathEscape(vaultID) + "/items"
+	itemID, err := p.resolveID(ctx, itemsPath, "title", itemRef)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve 1Password item %s: %w", itemRef, err)
+	}
+
+	var item onePasswordItem
+	if err := p.get(ctx, itemsPath+"/"+url.PathEscape(itemID), nil, &item); err != nil {
+		return nil, fmt.Errorf("failed to fetch 1Password item %s: %w", path, err)
+	}
+
+	return flattenOnePasswordItem(&item), nil
+}
+
+// resolveID looks up an object by name using a Connect list filter, falling
+// back to treating ref as an ID when nothing matches.
+func (p *OnePasswordProvider) resolveID(ctx context.Context, listPath, attribute, ref string) (string, error) {
+	query := url.Values{"filter": {fmt.Sprintf("%s eq %q", attribute, ref)}}
+
+	var matches []struct {
+		ID string `json:"id"`
+	}
+	if err := p.get(ctx, listPath, query, &matches); err != nil {
+		return "", err
+	}
+
+	switch len(matches) {
+	case 0:
+		return ref, nil
+	case 1:
+		return matches[0].ID, nil
+	default:
+		return "", fmt.Errorf("%d objects match %q; use the ID instead", len(matches), ref)
+	}
+}
+
+// flattenOnePasswordItem converts item fields into key-value pairs.
+func flattenOnePasswordItem(item *onePasswordItem) map[string]string {
+	sectionLabels := make(map[string]string, len(item.Sections))
+	for _, section := range item.Sections {
+		sectionLabels[section.ID] = section.Label
+	}
+
+	labelCount := make(map[string]int, len(item.Fields))
+	for _, field := range item.Fields {
+		labelCount[fieldKey(field.Label, field.ID)]++
+	}
+
+	secretData := make(map[string]string, len(item.Fields))
+	for _, field := range item.Fields {
+		key := fieldKey(field.Label, field.ID)
+		if labelCount[key] > 1 && field.Section != nil && sectionLabels[field.Section.ID] != "" {
+			key = sectionLabels[field.Section.ID] + "." + key
+		}
+		secretData[key] = field.Value
+	}
+	return secretData
+}
+
+// fieldKey returns the field label, or its ID for unlabeled fields.
+func fieldKey(label, id string) string {
+	if label != "" {
+		return label
+	}
+	return id
+}
+
+// get performs an authenticated GET against the Connect API and decodes the
+// JSON response into out.
+func (p *OnePasswordProvider) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	reqURL := p.host + path
+	if len(query) > 0 {
+		reqURL += "?" + query.Encode()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, reqURL, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create 1Password request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+p.token)
+
+	if err := doJSON(p.httpClient, req, out, onePasswordErrorMessage); err != nil {
+		return fmt.Errorf("1Password Connect request failed: %w", err)
+	}
+	return nil
+}
+
+// onePasswordErrorMessage extracts the message of a Connect error response.
+func onePasswordErrorMessage(body []byte) string {
+	var errResponse struct {
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &errResponse)
+	return errResponse.Message
+}
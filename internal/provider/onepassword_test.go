@@ -0,0 +1,126 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newFakeConnect starts an httptest server that mimics the 1Password Connect
+// API with a "Production" vault holding a "Database" item.
+func newFakeConnect(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/vaults", func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("filter") {
+		case `name eq "Production"`:
+			w.Write([]byte(`[{"id":"vault1","name":"Production"}]`))
+		case `name eq "Shared"`:
+			w.Write([]byte(`[{"id":"vault2","name":"Shared"},{"id":"vault3","name":"Shared"}]`))
+		default:
+			w.Write([]byte(`[]`))
+		}
+	})
+	mux.HandleFunc("/v1/vaults/vault1/items", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("filter") == `title eq "Database"` {
+			w.Write([]byte(`[{"id":"item1","title":"Database"}]`))
+			return
+		}
+		w.Write([]byte(`[]`))
+	})
+	mux.HandleFunc("/v1/vaults/vault1/items/item1", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{
+			"id": "item1",
+			"title": "Database",
+			"sections": [{"id": "primary", "label": "primary"}, {"id": "replica", "label": "replica"}],
+			"fields": [
+				{"id": "username", "label": "username", "value": "admin", "purpose": "USERNAME"},
+				{"id": "password", "label": "password", "value": "s3cret", "purpose": "PASSWORD"},
+				{"id": "h1", "label": "host", "value": "db1.local", "section": {"id": "primary"}},
+				{"id": "h2", "label": "host", "value": "db2.local", "section": {"id": "replica"}},
+				{"id": "notesPlain", "value": ""}
+			]
+		}`))
+	})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer connect-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"status":401,"message":"Invalid token signature"}`))
+			return
+		}
+		mux.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOnePasswordProvider_Name(t *testing.T) {
+	provider := &OnePasswordProvider{}
+	if got := provider.Name(); got != "onepassword" {
+		t.Errorf("Name() = %v, want %v", got, "onepassword")
+	}
+}
+
+func TestOnePasswordProvider_FetchSecret(t *testing.T) {
+	server := newFakeConnect(t)
+
+	want := map[string]string{
+		"username":     "admin",
+		"password":     "s3cret",
+		"primary.host": "db1.local",
+		"replica.host": "db2.local",
+		"notesPlain":   "",
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		path    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "By names", token: "connect-token", path: "Production/Database", want: want},
+		{name: "By IDs", token: "connect-token", path: "vault1/item1", want: want},
+		{name: "Ambiguous vault name", token: "connect-token", path: "Shared/Database", wantErr: true},
+		{name: "Missing item", token: "connect-token", path: "Production/Missing", wantErr: true},
+		{name: "Invalid token", token: "wrong", path: "Production/Database", wantErr: true},
+		{name: "Malformed path", token: "connect-token", path: "Production", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewOnePasswordProvider(OnePasswordConfig{Host: server.URL, Token: tt.token})
+			if err != nil {
+				t.Fatalf("NewOnePasswordProvider() error = %v", err)
+			}
+
+			got, err := provider.FetchSecret(context.Background(), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d keys, want %d keys: %v", len(got), len(tt.want), got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("key %q: got %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestNewOnePasswordProvider_Validation(t *testing.T) {
+	if _, err := NewOnePasswordProvider(OnePasswordConfig{Token: "t"}); err == nil {
+		t.Error("NewOnePasswordProvider() expected error for missing host")
+	}
+	if _, err := NewOnePasswordProvider(OnePasswordConfig{Host: "http://connect"}); err == nil {
+		t.Error("NewOnePasswordProvider() expected error for missing token")
+	}
+}
@@ -5,7 +5,13 @@ package provider
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"slices"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
 // SecretProvider is the interface for external secret sources.
@@ -20,56 +26,443 @@ type SecretProvider interface {
 	Name() string
 }
 
+// SecretValue is a fetched secret together with metadata reported by the provider.
+type SecretValue struct {
+	// Data holds the secret's key-value pairs. Values may be binary.
+	Data map[string][]byte
+	// Version identifies the version that was read. It is empty when the
+	// provider does not report versions.
+	Version string
+	// Type is the Kubernetes type of the secret that was read, for providers
+	// copying Kubernetes Secrets. It is empty for other providers.
+	Type corev1.SecretType
+}
+
+// SecretValueProvider is implemented by providers that report metadata about
+// the secrets they fetch, or that serve binary values.
+type SecretValueProvider interface {
+	SecretProvider
+
+	// FetchSecretValue retrieves a secret from the provider at the given
+	// path together with its metadata.
+	FetchSecretValue(ctx context.Context, path string) (*SecretValue, error)
+}
+
+// FetchOptionsProvider is implemented by providers that use some of the
+// FetchOptions settings.
+type FetchOptionsProvider interface {
+	SecretProvider
+
+	// SupportedFetchOptions lists the FetchOptions settings the provider
+	// uses, by their annotation field name (e.g. "region").
+	SupportedFetchOptions() []string
+}
+
+// Fetch retrieves a secret from p with the metadata p reports, if any. It
+// fails if the FetchOptions of ctx set anything p does not support.
+func Fetch(ctx context.Context, p SecretProvider, path string) (*SecretValue, error) {
+	if err := CheckFetchOptions(p, FetchOptionsFrom(ctx)); err != nil {
+		return nil, err
+	}
+	if valueProvider, ok := p.(SecretValueProvider); ok {
+		return valueProvider.FetchSecretValue(ctx, path)
+	}
+	data, err := p.FetchSecret(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretValue{Data: bytesSecretData(data)}, nil
+}
+
+// bytesSecretData converts string key-value pairs to the []byte values of
+// a SecretValue.
+func bytesSecretData(data map[string]string) map[string][]byte {
+	byteData := make(map[string][]byte, len(data))
+	for key, value := range data {
+		byteData[key] = []byte(value)
+	}
+	return byteData
+}
+
+// stringSecretData converts the values of a SecretValue to strings. Binary
+// values are kept byte for byte.
+func stringSecretData(data map[string][]byte) map[string]string {
+	stringData := make(map[string]string, len(data))
+	for key, value := range data {
+		stringData[key] = string(value)
+	}
+	return stringData
+}
+
+// requestNamespaceKey is the context key for the requesting pod's namespace.
+type requestNamespaceKey struct{}
+
+// WithRequestNamespace returns a context carrying the namespace of the pod a
+// secret is being fetched for. Providers that enforce per-namespace access
+// read it back with RequestNamespace.
+func WithRequestNamespace(ctx context.Context, namespace string) context.Context {
+	return context.WithValue(ctx, requestNamespaceKey{}, namespace)
+}
+
+// RequestNamespace returns the namespace set by WithRequestNamespace, or an
+// empty string if none was set.
+func RequestNamespace(ctx context.Context) string {
+	namespace, _ := ctx.Value(requestNamespaceKey{}).(string)
+	return namespace
+}
+
+// FetchOptions holds per-request settings from the pod annotation that only
+// some providers use (see FetchOptionsProvider).
+type FetchOptions struct {
+	// Region selects the region of region-scoped providers (AWS).
+	Region string
+	// Version selects a specific secret version (AWS Secrets Manager VersionId).
+	Version string
+	// VersionStage selects the version carrying a staging label (AWS Secrets
+	// Manager, e.g. "AWSPENDING" or "AWSPREVIOUS").
+	VersionStage string
+	// Format selects how a secret string is decoded (AWS Secrets Manager):
+	// FormatJSON (the default) or FormatText.
+	Format string
+	// TextKey is the key a FormatText value is stored under. Defaults to the
+	// secret's name.
+	TextKey string
+}
+
+const (
+	// FormatJSON decodes secret strings as a JSON object of key-value pairs.
+	FormatJSON = "json"
+	// FormatText keeps secret strings as-is under a single key.
+	FormatText = "text"
+)
+
+// CheckFetchOptions returns an error naming the first setting of opts that
+// p does not support.
+func CheckFetchOptions(p SecretProvider, opts FetchOptions) error {
+	var supported []string
+	if optionsProvider, ok := p.(FetchOptionsProvider); ok {
+		supported = optionsProvider.SupportedFetchOptions()
+	}
+
+	settings := []struct {
+		name  string
+		value string
+	}{
+		{"region", opts.Region},
+		{"version", opts.Version},
+		{"versionStage", opts.VersionStage},
+		{"format", opts.Format},
+		{"textKey", opts.TextKey},
+	}
+	for _, setting := range settings {
+		if setting.value != "" && !slices.Contains(supported, setting.name) {
+			return fmt.Errorf("provider %s does not support %s", p.Name(), setting.name)
+		}
+	}
+	return nil
+}
+
+// fetchOptionsKey is the context key for FetchOptions.
+type fetchOptionsKey struct{}
+
+// WithFetchOptions returns a context carrying per-request fetch settings.
+// Providers read them back with FetchOptionsFrom.
+func WithFetchOptions(ctx context.Context, opts FetchOptions) context.Context {
+	return context.WithValue(ctx, fetchOptionsKey{}, opts)
+}
+
+// FetchOptionsFrom returns the settings set by WithFetchOptions, or zero
+// FetchOptions if none were set.
+func FetchOptionsFrom(ctx context.Context) FetchOptions {
+	opts, _ := ctx.Value(fetchOptionsKey{}).(FetchOptions)
+	return opts
+}
+
 // ProviderRegistry manages available secret providers.
+//
+// Besides the providers registered at startup, it holds providers created
+// from SecretStore and ClusterSecretStore objects, which are added and
+// removed while the controller runs. It is safe for concurrent use.
 type ProviderRegistry struct {
+	mu        sync.RWMutex
 	providers map[string]SecretProvider
+	stores    map[storeKey]SecretProvider
+}
+
+// storeKey identifies a store-backed provider. namespace is empty for
+// cluster-scoped stores.
+type storeKey struct {
+	namespace string
+	name      string
 }
 
 // NewProviderRegistry creates a new provider registry.
 func NewProviderRegistry() *ProviderRegistry {
 	return &ProviderRegistry{
 		providers: make(map[string]SecretProvider),
+		stores:    make(map[storeKey]SecretProvider),
 	}
 }
 
-// Register adds a provider to the registry.
+// Register adds a provider to the registry under its Name.
 func (r *ProviderRegistry) Register(provider SecretProvider) {
-	r.providers[provider.Name()] = provider
+	r.RegisterAs(provider.Name(), provider)
 }
 
-// Get retrieves a provider by name.
-// Returns nil if the provider is not found.
+// RegisterAs adds a provider to the registry under the given name, replacing
+// any provider already registered with that name.
+func (r *ProviderRegistry) RegisterAs(name string, provider SecretProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[name] = provider
+}
+
+// Registered reports whether a provider was registered at startup under name.
+func (r *ProviderRegistry) Registered(name string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.providers[name]
+	return ok
+}
+
+// SetStore adds or replaces the provider backing a store. namespace is empty
+// for a ClusterSecretStore.
+func (r *ProviderRegistry) SetStore(namespace, name string, provider SecretProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.stores[storeKey{namespace: namespace, name: name}] = provider
+}
+
+// RemoveStore removes the provider backing a store, if any.
+func (r *ProviderRegistry) RemoveStore(namespace, name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.stores, storeKey{namespace: namespace, name: name})
+}
+
+// Get retrieves a provider by name, either a ClusterSecretStore or a provider
+// registered at startup. Returns nil if the provider is not found.
 func (r *ProviderRegistry) Get(name string) SecretProvider {
+	return r.Resolve("", name)
+}
+
+// Resolve retrieves the provider a pod in namespace refers to by name. A
+// SecretStore in the namespace takes precedence over a ClusterSecretStore
+// or a provider registered at startup; the store controller keeps
+// ClusterSecretStores from reusing the names of the latter.
+// Returns nil if the provider is not found.
+func (r *ProviderRegistry) Resolve(namespace, name string) SecretProvider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if namespace != "" {
+		if provider, ok := r.stores[storeKey{namespace: namespace, name: name}]; ok {
+			return provider
+		}
+	}
+	if provider, ok := r.stores[storeKey{name: name}]; ok {
+		return provider
+	}
 	return r.providers[name]
 }
 
-// List returns all registered provider names.
+// List returns all registered provider names, each once. Providers backed
+// by a SecretStore are listed as "namespace/name".
 func (r *ProviderRegistry) List() []string {
-	names := make([]string, 0, len(r.providers))
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.providers)+len(r.stores))
 	for name := range r.providers {
+		if _, shadowed := r.stores[storeKey{name: name}]; shadowed {
+			continue
+		}
 		names = append(names, name)
 	}
+	for key := range r.stores {
+		if key.namespace != "" {
+			names = append(names, key.namespace+"/"+key.name)
+		} else {
+			names = append(names, key.name)
+		}
+	}
 	return names
 }
 
+// RegistryOptions configures the providers created by DefaultProviderRegistry.
+type RegistryOptions struct {
+	// ConfigFile is a provider configuration file (see ProvidersConfig).
+	// When set, only the instances it declares are registered and the
+	// options below serve as defaults for their settings.
+	ConfigFile string
+	// AWS configures the AWS Secrets Manager and SSM providers. KubeClient
+	// is used to read Namespaces unless AWS.Client is set.
+	AWS AWSConfig
+	// Vault configures the Vault KV provider. It is only registered when
+	// Vault.Address is set.
+	Vault VaultConfig
+	// Azure configures the Azure Key Vault provider. It is only registered
+	// when Azure.TenantID and Azure.ClientID are set.
+	Azure AzureKeyVaultConfig
+	// GCP configures the GCP Secret Manager provider. It is only registered
+	// when GCP.Enabled is set.
+	GCP GCPSecretManagerConfig
+	// KubeClient reads Secrets for the kubernetes provider. It is only
+	// registered when KubeClient is set.
+	KubeClient client.Reader
+	// FileRoot is the directory served by the file provider. It is only
+	// registered when FileRoot is set.
+	FileRoot string
+	// SOPS configures the SOPS provider. It is only registered when
+	// SOPS.AgeKeyFile is set. KubeClient is used for ConfigMap sources
+	// unless SOPS.Client is set.
+	SOPS SOPSConfig
+	// OnePassword configures the 1Password Connect provider. It is only
+	// registered when OnePassword.Host is set.
+	OnePassword OnePasswordConfig
+	// Bitwarden configures the Bitwarden Secrets Manager provider. It is only
+	// registered when Bitwarden.AccessToken is set.
+	Bitwarden BitwardenConfig
+}
+
 // DefaultProviderRegistry creates a registry with all available providers.
 // This is the main entry point for initializing providers in the controller.
-func DefaultProviderRegistry(ctx context.Context) (*ProviderRegistry, error) {
+func DefaultProviderRegistry(ctx context.Context, opts RegistryOptions) (*ProviderRegistry, error) {
+	if opts.ConfigFile != "" {
+		return providerRegistryFromConfig(ctx, opts)
+	}
+
 	registry := NewProviderRegistry()
 
+	awsConfig := opts.AWS
+	if awsConfig.Client == nil {
+		awsConfig.Client = opts.KubeClient
+	}
+
 	// Register AWS Secrets Manager provider
-	awsProvider, err := NewAWSSecretsManagerProvider(ctx)
+	awsProvider, err := NewAWSSecretsManagerProviderWithConfig(ctx, awsConfig)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create AWS provider: %w", err)
 	}
 	registry.Register(awsProvider)
 
-	// Future providers can be added here:
-	// vaultProvider, err := NewVaultProvider(ctx)
-	// if err != nil {
-	//     return nil, fmt.Errorf("failed to create Vault provider: %w", err)
-	// }
-	// registry.Register(vaultProvider)
+	// Register AWS SSM Parameter Store provider
+	ssmProvider, err := NewAWSSSMParameterStoreProviderWithConfig(ctx, awsConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS SSM provider: %w", err)
+	}
+	registry.Register(ssmProvider)
+
+	// Register HashiCorp Vault KV provider when a Vault address is configured
+	if opts.Vault.Address != "" {
+		vaultProvider, err := NewVaultKVProvider(opts.Vault)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Vault provider: %w", err)
+		}
+		registry.Register(vaultProvider)
+	}
+
+	// Register Azure Key Vault provider when an Azure identity is configured
+	if opts.Azure.TenantID != "" && opts.Azure.ClientID != "" {
+		azureProvider, err := NewAzureKeyVaultProvider(opts.Azure)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Azure Key Vault provider: %w", err)
+		}
+		registry.Register(azureProvider)
+	}
+
+	// Register GCP Secret Manager provider when enabled
+	if opts.GCP.Enabled {
+		gcpProvider, err := NewGCPSecretManagerProvider(opts.GCP)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create GCP Secret Manager provider: %w", err)
+		}
+		registry.Register(gcpProvider)
+	}
+
+	// Register Kubernetes cross-namespace Secret provider
+	if opts.KubeClient != nil {
+		kubernetesProvider, err := NewKubernetesSecretProvider(opts.KubeClient)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Kubernetes provider: %w", err)
+		}
+		registry.Register(kubernetesProvider)
+	}
+
+	// Register local file provider when a root directory is configured
+	if opts.FileRoot != "" {
+		fileProvider, err := NewFileProvider(opts.FileRoot)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create file provider: %w", err)
+		}
+		registry.Register(fileProvider)
+	}
+
+	// Register SOPS provider when an age key is configured
+	if opts.SOPS.AgeKeyFile != "" {
+		sopsConfig := opts.SOPS
+		if sopsConfig.Client == nil {
+			sopsConfig.Client = opts.KubeClient
+		}
+		sopsProvider, err := NewSOPSProvider(sopsConfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create SOPS provider: %w", err)
+		}
+		registry.Register(sopsProvider)
+	}
+
+	// Register 1Password Connect provider when a Connect host is configured
+	if opts.OnePassword.Host != "" {
+		onePasswordProvider, err := NewOnePasswordProvider(opts.OnePassword)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create 1Password provider: %w", err)
+		}
+		registry.Register(onePasswordProvider)
+	}
+
+	// Register Bitwarden Secrets Manager provider when an access token is configured
+	if opts.Bitwarden.AccessToken != "" {
+		bitwardenProvider, err := NewBitwardenProvider(opts.Bitwarden)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create Bitwarden provider: %w", err)
+		}
+		registry.Register(bitwardenProvider)
+	}
 
 	return registry, nil
 }
+
+// stringifySecretData converts a decoded JSON object into string key-value pairs.
+// Scalars are formatted as-is, nulls become empty strings, and complex types
+// (objects, arrays) are re-encoded as JSON.
+func stringifySecretData(rawData map[string]interface{}) map[string]string {
+	secretData := make(map[string]string, len(rawData))
+	for key, value := range rawData {
+		switch v := value.(type) {
+		case string:
+			secretData[key] = v
+		case float64:
+			secretData[key] = fmt.Sprintf("%v", v)
+		case bool:
+			secretData[key] = fmt.Sprintf("%v", v)
+		case nil:
+			secretData[key] = ""
+		default:
+			// For complex types (objects, arrays), convert to JSON
+			jsonBytes, _ := json.Marshal(v)
+			secretData[key] = string(jsonBytes)
+		}
+	}
+	return secretData
+}
+
+// parseSecretPayload decodes a secret payload holding a JSON object into
+// string key-value pairs. Payloads that are not a JSON object are returned
+// as-is under fallbackKey.
+func parseSecretPayload(payload, fallbackKey string) map[string]string {
+	var rawData map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &rawData); err != nil || rawData == nil {
+		return map[string]string{fallbackKey: payload}
+	}
+	return stringifySecretData(rawData)
+}
@@ -0,0 +1,118 @@
+package provider
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// staticProvider is a SecretProvider returning fixed data.
+type staticProvider struct {
+	name string
+	data map[string]string
+}
+
+func (p *staticProvider) FetchSecret(ctx context.Context, path string) (map[string]string, error) {
+	return p.data, nil
+}
+
+func (p *staticProvider) Name() string {
+	return p.name
+}
+
+func TestProviderRegistry_Resolve(t *testing.T) {
+	builtin := &staticProvider{name: "vault-kv"}
+	clusterStore := &staticProvider{name: "vault-kv"}
+	namespacedStore := &staticProvider{name: "vault-kv"}
+
+	registry := NewProviderRegistry()
+	registry.Register(builtin)
+	registry.SetStore("", "vault-kv", clusterStore)
+	registry.SetStore("team-a", "vault-kv", namespacedStore)
+
+	if got := registry.Resolve("team-a", "vault-kv"); got != namespacedStore {
+		t.Error("Resolve() should prefer the SecretStore in the pod's namespace")
+	}
+	if got := registry.Resolve("team-b", "vault-kv"); got != clusterStore {
+		t.Error("Resolve() should fall back to the ClusterSecretStore")
+	}
+	if got := registry.Get("vault-kv"); got != clusterStore {
+		t.Error("Get() should prefer the ClusterSecretStore")
+	}
+
+	names := registry.List()
+	sort.Strings(names)
+	if want := "team-a/vault-kv,vault-kv"; strings.Join(names, ",") != want {
+		t.Errorf("List() = %v, want %s", names, want)
+	}
+
+	registry.RemoveStore("", "vault-kv")
+	registry.RemoveStore("team-a", "vault-kv")
+	if got := registry.Resolve("team-a", "vault-kv"); got != builtin {
+		t.Error("Resolve() should return the built-in provider once stores are removed")
+	}
+	if !registry.Registered("vault-kv") || registry.Registered("missing") {
+		t.Error("Registered() should report only providers registered at startup")
+	}
+	if got := registry.Resolve("team-a", "missing"); got != nil {
+		t.Errorf("Resolve() = %v, want nil", got)
+	}
+}
+
+func TestFetch_WithoutMetadata(t *testing.T) {
+	secretProvider := &staticProvider{name: "static", data: map[string]string{"k": "v"}}
+
+	got, err := Fetch(context.Background(), secretProvider, "path")
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if string(got.Data["k"]) != "v" || got.Version != "" {
+		t.Errorf("Fetch() = %+v, want data without version", got)
+	}
+}
+
+// regionalProvider is a staticProvider supporting the region setting.
+type regionalProvider struct {
+	staticProvider
+}
+
+func (p *regionalProvider) SupportedFetchOptions() []string {
+	return []string{"region"}
+}
+
+func TestFetch_UnsupportedOptions(t *testing.T) {
+	static := &staticProvider{name: "static", data: map[string]string{"k": "v"}}
+	regional := &regionalProvider{staticProvider{name: "regional", data: map[string]string{"k": "v"}}}
+
+	tests := []struct {
+		name     string
+		provider SecretProvider
+		opts     FetchOptions
+		wantErr  string
+	}{
+		{name: "no options", provider: static},
+		{name: "region on static", provider: static, opts: FetchOptions{Region: "eu-west-1"}, wantErr: "static does not support region"},
+		{name: "version on static", provider: static, opts: FetchOptions{Version: "v1"}, wantErr: "does not support version"},
+		{name: "versionStage on static", provider: static, opts: FetchOptions{VersionStage: "AWSPREVIOUS"}, wantErr: "does not support versionStage"},
+		{name: "format on static", provider: static, opts: FetchOptions{Format: FormatText}, wantErr: "does not support format"},
+		{name: "region on regional", provider: regional, opts: FetchOptions{Region: "eu-west-1"}},
+		{name: "version on regional", provider: regional, opts: FetchOptions{Region: "eu-west-1", Version: "v1"}, wantErr: "regional does not support version"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := WithFetchOptions(context.Background(), tt.opts)
+			_, err := Fetch(ctx, tt.provider, "path")
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Fetch() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Fetch() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
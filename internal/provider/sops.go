@@ -0,0 +1,626 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// sopsConfigMapPrefix selects a ConfigMap source in a sops provider path.
+	sopsConfigMapPrefix = "configmap:"
+	// sopsMetadataKey is the top-level key holding SOPS metadata.
+	sopsMetadataKey = "sops"
+	// sopsDefaultUnencryptedSuffix is the unencrypted_suffix SOPS applies
+	// when a document sets no rule selecting the values to encrypt.
+	sopsDefaultUnencryptedSuffix = "_unencrypted"
+)
+
+// sopsValuePattern matches a SOPS-encrypted value, e.g.
+// ENC[AES256_GCM,data:...,iv:...,tag:...,type:str].
+var sopsValuePattern = regexp.MustCompile(`^ENC\[AES256_GCM,data:(.*),iv:(.+),tag:(.+),type:(.+)\]$`)
+
+// SOPSConfig holds the settings for the SOPS provider.
+type SOPSConfig struct {
+	// AgeKeyFile is a file holding one or more age private keys
+	// ("AGE-SECRET-KEY-..." lines, as written by age-keygen).
+	AgeKeyFile string `yaml:"ageKeyFile"`
+	// Root is the directory encrypted files are read from (optional).
+	Root string `yaml:"root"`
+	// Client reads ConfigMaps holding encrypted documents (optional).
+	Client client.Reader `yaml:"-"`
+}
+
+// SOPSProvider implements SecretProvider for SOPS-encrypted YAML or JSON
+// documents whose data key is encrypted for an age recipient.
+//
+// Paths are either a file below the configured root (e.g. "prod/db.enc.yaml")
+// or "configmap:<namespace>/<name>/<key>". ConfigMaps are subject to the same
+// KubernetesAllowedNamespacesAnnotation check as the kubernetes provider.
+//
+// Each value is authenticated by AES-GCM and bound to its key path, and the
+// document as a whole by the SOPS MAC, so that values cannot be added,
+// removed or left unencrypted outside the document's unencrypted_suffix,
+// encrypted_suffix, unencrypted_regex and encrypted_regex rules.
+type SOPSProvider struct {
+	identities []age.Identity
+	root       string
+	client     client.Reader
+}
+
+// NewSOPSProvider creates a new SOPS provider.
+func NewSOPSProvider(cfg SOPSConfig) (*SOPSProvider, error) {
+	keyFile, err := os.Open(cfg.AgeKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open age key file: %w", err)
+	}
+	defer keyFile.Close()
+
+	identities, err := age.ParseIdentities(keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age key file: %w", err)
+	}
+
+	return &SOPSProvider{
+		identities: identities,
+		root:       cfg.Root,
+		client:     cfg.Client,
+	}, nil
+}
+
+// Name returns the provider identifier.
+func (p *SOPSProvider) Name() string {
+	return "sops"
+}
+
+// FetchSecret reads and decrypts a SOPS document. Top-level keys become
+// secret keys; nested values are encoded as JSON.
+func (p *SOPSProvider) FetchSecret(ctx context.Context, path string) (map[string]string, error) {
+	var (
+		content []byte
+		err     error
+	)
+	if strings.HasPrefix(path, sopsConfigMapPrefix) {
+		content, err = p.readConfigMap(ctx, strings.TrimPrefix(path, sopsConfigMapPrefix))
+	} else {
+		content, err = p.readFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rawData, err := p.decrypt(content)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt SOPS document %s: %w", path, err)
+	}
+	return stringifySecretData(rawData), nil
+}
+
+// readFile reads an encrypted document below the configured root.
+func (p *SOPSProvider) readFile(path string) ([]byte, error) {
+	if p.root == "" {
+		return nil, fmt.Errorf("sops provider has no file root configured")
+	}
+
+	root, err := os.OpenRoot(p.root)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sops root: %w", err)
+	}
+	defer root.Close()
+
+	content, err := readRootFile(root, filepath.Clean(strings.TrimPrefix(path, "/")))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return content, nil
+}
+
+// readConfigMap reads an encrypted document from "<namespace>/<name>/<key>".
+func (p *SOPSProvider) readConfigMap(ctx context.Context, ref string) ([]byte, error) {
+	if p.client == nil {
+		return nil, fmt.Errorf("sops provider has no Kubernetes client configured")
+	}
+
+	parts := strings.Split(ref, "/")
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return nil, fmt.Errorf("invalid sops ConfigMap reference %q: expected %s<namespace>/<name>/<key>", ref, sopsConfigMapPrefix)
+	}
+	namespace, name, key := parts[0], parts[1], parts[2]
+
+	requestNamespace := RequestNamespace(ctx)
+	if requestNamespace == "" {
+		return nil, fmt.Errorf("requesting namespace is unknown")
+	}
+
+	var configMap corev1.ConfigMap
+	if err := p.client.Get(ctx, client.ObjectKey{Namespace: namespace, Name: name}, &configMap); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("configmap %s/%s not found", namespace, name)
+		}
+		return nil, fmt.Errorf("failed to get configmap %s/%s: %w", namespace, name, err)
+	}
+
+	if !namespaceAllowed(&configMap, requestNamespace) {
+		return nil, fmt.Errorf("namespace %s is not allowed to read configmap %s/%s (see %s annotation)",
+			requestNamespace, namespace, name, KubernetesAllowedNamespacesAnnotation)
+	}
+
+	content, ok := configMap.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("configmap %s/%s has no key %s", namespace, name, key)
+	}
+	return []byte(content), nil
+}
+
+// sopsMetadata is the part of the SOPS metadata the provider uses.
+type sopsMetadata struct {
+	Age []struct {
+		Recipient string `yaml:"recipient"`
+		Enc       string `yaml:"enc"`
+	} `yaml:"age"`
+	LastModified      string `yaml:"lastmodified"`
+	MAC               string `yaml:"mac"`
+	MACOnlyEncrypted  bool   `yaml:"mac_only_encrypted"`
+	UnencryptedSuffix string `yaml:"unencrypted_suffix"`
+	EncryptedSuffix   string `yaml:"encrypted_suffix"`
+	UnencryptedRegex  string `yaml:"unencrypted_regex"`
+	EncryptedRegex    string `yaml:"encrypted_regex"`
+}
+
+// decrypt decrypts a SOPS YAML or JSON document (JSON is parsed as YAML) and
+// verifies its MAC.
+func (p *SOPSProvider) decrypt(content []byte) (map[string]interface{}, error) {
+	var document yaml.Node
+	if err := yaml.Unmarshal(content, &document); err != nil {
+		return nil, fmt.Errorf("failed to parse document: %w", err)
+	}
+	if len(document.Content) != 1 || document.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("document is not a mapping")
+	}
+	top := document.Content[0]
+
+	var metadataNode *yaml.Node
+	for i := 0; i+1 < len(top.Content); i += 2 {
+		if top.Content[i].Value == sopsMetadataKey {
+			metadataNode = top.Content[i+1]
+		}
+	}
+	if metadataNode == nil {
+		return nil, fmt.Errorf("document has no %q metadata", sopsMetadataKey)
+	}
+	var metadata sopsMetadata
+	if err := metadataNode.Decode(&metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse sops metadata: %w", err)
+	}
+
+	dataKey, err := p.dataKey(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	decryptor, err := newSOPSDecryptor(metadata, dataKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// The document node is walked like SOPS builds its tree, so that
+	// comments are authenticated in the same order.
+	if err := decryptor.comments(document.HeadComment, nil); err != nil {
+		return nil, err
+	}
+	if err := decryptor.comments(document.LineComment, nil); err != nil {
+		return nil, err
+	}
+	rawData, err := decryptor.mapping(top, nil, false)
+	if err != nil {
+		return nil, err
+	}
+	if err := decryptor.comments(document.FootComment, nil); err != nil {
+		return nil, err
+	}
+
+	if err := decryptor.verifyMAC(metadata); err != nil {
+		return nil, err
+	}
+	return rawData, nil
+}
+
+// dataKey recovers the document data key from the age entries in metadata.
+func (p *SOPSProvider) dataKey(metadata sopsMetadata) ([]byte, error) {
+	if len(metadata.Age) == 0 {
+		return nil, fmt.Errorf("document has no age recipients")
+	}
+
+	for _, entry := range metadata.Age {
+		reader, err := age.Decrypt(armor.NewReader(strings.NewReader(entry.Enc)), p.identities...)
+		if err != nil {
+			continue
+		}
+		dataKey, err := io.ReadAll(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read data key for %s: %w", entry.Recipient, err)
+		}
+		return dataKey, nil
+	}
+	return nil, fmt.Errorf("none of the configured age keys can decrypt the data key")
+}
+
+// sopsDecryptor decrypts the values of a SOPS document and computes its MAC:
+// a SHA-512 digest over the plaintext of every value and comment, in
+// document order. Values excluded from encryption are hashed too, unless
+// the document was encrypted with mac_only_encrypted.
+type sopsDecryptor struct {
+	dataKey           []byte
+	macOnlyEncrypted  bool
+	unencryptedSuffix string
+	encryptedSuffix   string
+	unencryptedRegex  *regexp.Regexp
+	encryptedRegex    *regexp.Regexp
+	mac               hash.Hash
+}
+
+// newSOPSDecryptor returns a decryptor for a document with metadata.
+func newSOPSDecryptor(metadata sopsMetadata, dataKey []byte) (*sopsDecryptor, error) {
+	d := &sopsDecryptor{
+		dataKey:           dataKey,
+		macOnlyEncrypted:  metadata.MACOnlyEncrypted,
+		unencryptedSuffix: metadata.UnencryptedSuffix,
+		encryptedSuffix:   metadata.EncryptedSuffix,
+		mac:               sha512.New(),
+	}
+
+	var err error
+	if metadata.UnencryptedRegex != "" {
+		if d.unencryptedRegex, err = regexp.Compile(metadata.UnencryptedRegex); err != nil {
+			return nil, fmt.Errorf("invalid unencrypted_regex: %w", err)
+		}
+	}
+	if metadata.EncryptedRegex != "" {
+		if d.encryptedRegex, err = regexp.Compile(metadata.EncryptedRegex); err != nil {
+			return nil, fmt.Errorf("invalid encrypted_regex: %w", err)
+		}
+	}
+	// SOPS documents without any selection rule use its default suffix.
+	if d.unencryptedSuffix == "" && d.encryptedSuffix == "" && d.unencryptedRegex == nil && d.encryptedRegex == nil {
+		d.unencryptedSuffix = sopsDefaultUnencryptedSuffix
+	}
+	return d, nil
+}
+
+// encrypted reports whether the values at path are encrypted according to
+// the document's unencrypted_suffix, encrypted_suffix, unencrypted_regex and
+// encrypted_regex rules.
+func (d *sopsDecryptor) encrypted(path []string) bool {
+	encrypted := true
+	if d.unencryptedSuffix != "" {
+		for _, key := range path {
+			if strings.HasSuffix(key, d.unencryptedSuffix) {
+				encrypted = false
+				break
+			}
+		}
+	}
+	if d.encryptedSuffix != "" {
+		encrypted = false
+		for _, key := range path {
+			if strings.HasSuffix(key, d.encryptedSuffix) {
+				encrypted = true
+				break
+			}
+		}
+	}
+	if d.unencryptedRegex != nil {
+		for _, key := range path {
+			if d.unencryptedRegex.MatchString(key) {
+				encrypted = false
+				break
+			}
+		}
+	}
+	if d.encryptedRegex != nil {
+		encrypted = false
+		for _, key := range path {
+			if d.encryptedRegex.MatchString(key) {
+				encrypted = true
+				break
+			}
+		}
+	}
+	return encrypted
+}
+
+// mapping decrypts a mapping node. path is the list of mapping keys leading
+// to node; commentsHandled is set when the node's own comments were already
+// taken into account by its parent.
+func (d *sopsDecryptor) mapping(node *yaml.Node, path []string, commentsHandled bool) (map[string]interface{}, error) {
+	if !commentsHandled {
+		if err := d.comments(node.HeadComment, path); err != nil {
+			return nil, err
+		}
+		if err := d.comments(node.LineComment, path); err != nil {
+			return nil, err
+		}
+	}
+
+	result := make(map[string]interface{}, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if err := d.comments(key.HeadComment, path); err != nil {
+			return nil, err
+		}
+		if err := d.comments(key.LineComment, path); err != nil {
+			return nil, err
+		}
+		scalar := value.Kind == yaml.ScalarNode || value.Kind == yaml.AliasNode
+		if scalar {
+			if err := d.comments(value.HeadComment, path); err != nil {
+				return nil, err
+			}
+			if err := d.comments(value.LineComment, path); err != nil {
+				return nil, err
+			}
+		}
+
+		// The metadata is not part of the authenticated data.
+		if len(path) > 0 || key.Value != sopsMetadataKey {
+			decrypted, err := d.value(value, append(path[:len(path):len(path)], key.Value), scalar)
+			if err != nil {
+				return nil, err
+			}
+			result[key.Value] = decrypted
+		}
+
+		if scalar {
+			if err := d.comments(value.FootComment, path); err != nil {
+				return nil, err
+			}
+		}
+		if err := d.comments(key.FootComment, path); err != nil {
+			return nil, err
+		}
+	}
+
+	if !commentsHandled {
+		if err := d.comments(node.FootComment, path); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// value decrypts node and its children. List items share their parent's
+// path, as in SOPS.
+func (d *sopsDecryptor) value(node *yaml.Node, path []string, commentsHandled bool) (interface{}, error) {
+	switch node.Kind {
+	case yaml.MappingNode:
+		return d.mapping(node, path, false)
+	case yaml.SequenceNode:
+		if !commentsHandled {
+			if err := d.comments(node.HeadComment, path); err != nil {
+				return nil, err
+			}
+			if err := d.comments(node.LineComment, path); err != nil {
+				return nil, err
+			}
+		}
+		result := make([]interface{}, 0, len(node.Content))
+		for _, item := range node.Content {
+			if err := d.comments(item.HeadComment, path); err != nil {
+				return nil, err
+			}
+			if err := d.comments(item.LineComment, path); err != nil {
+				return nil, err
+			}
+			value, err := d.value(item, path, true)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, value)
+			if err := d.comments(item.FootComment, path); err != nil {
+				return nil, err
+			}
+		}
+		return result, nil
+	case yaml.ScalarNode:
+		return d.scalar(node, path)
+	case yaml.AliasNode:
+		return d.value(node.Alias, path, false)
+	default:
+		return nil, fmt.Errorf("unsupported YAML node at %s", strings.Join(path, "."))
+	}
+}
+
+// scalar decrypts a scalar value. Values SOPS leaves in plaintext are
+// returned as-is; any other value must be encrypted.
+func (d *sopsDecryptor) scalar(node *yaml.Node, path []string) (interface{}, error) {
+	// SOPS neither encrypts nor authenticates null values.
+	if node.Tag == "!!null" {
+		return nil, nil
+	}
+
+	if !d.encrypted(path) {
+		var value interface{}
+		if err := node.Decode(&value); err != nil {
+			return nil, err
+		}
+		if !d.macOnlyEncrypted {
+			plaintext, err := sopsPlaintext(value)
+			if err != nil {
+				return nil, fmt.Errorf("unsupported value at %s: %w", strings.Join(path, "."), err)
+			}
+			d.mac.Write(plaintext)
+		}
+		if node.Tag == "!!int" || node.Tag == "!!float" {
+			return sopsNumber(node.Value), nil
+		}
+		return value, nil
+	}
+
+	if !sopsValuePattern.MatchString(node.Value) {
+		return nil, fmt.Errorf("value at %s is not encrypted", strings.Join(path, "."))
+	}
+	plaintext, valueType, err := decryptSOPSValue(node.Value, d.dataKey, sopsAdditionalData(path))
+	if err != nil {
+		return nil, err
+	}
+	d.mac.Write(plaintext)
+
+	switch valueType {
+	case "str", "bytes":
+		return string(plaintext), nil
+	case "int", "float":
+		// Numbers are kept verbatim rather than round-tripped through
+		// float64, which would turn 1234567 into 1.234567e+06.
+		return sopsNumber(string(plaintext)), nil
+	case "bool":
+		// SOPS encodes booleans as "True"/"False".
+		boolean, err := strconv.ParseBool(string(plaintext))
+		if err != nil {
+			return nil, fmt.Errorf("invalid bool value at %s: %w", strings.Join(path, "."), err)
+		}
+		return boolean, nil
+	default:
+		return nil, fmt.Errorf("unsupported SOPS value type %q at %s", valueType, strings.Join(path, "."))
+	}
+}
+
+// comments decrypts and authenticates the lines of a YAML comment attached
+// to a node of the mapping or list at path. SOPS encrypts each comment line
+// on its own.
+func (d *sopsDecryptor) comments(comment string, path []string) error {
+	for _, line := range strings.Split(comment, "\n") {
+		if line == "" {
+			continue
+		}
+		value := line[1:]
+
+		if !d.encrypted(path) {
+			if !d.macOnlyEncrypted {
+				d.mac.Write([]byte(value))
+			}
+			continue
+		}
+		if !sopsValuePattern.MatchString(value) {
+			return fmt.Errorf("comment at %s is not encrypted", strings.Join(path, "."))
+		}
+		plaintext, _, err := decryptSOPSValue(value, d.dataKey, sopsAdditionalData(path))
+		if err != nil {
+			return err
+		}
+		d.mac.Write(plaintext)
+	}
+	return nil
+}
+
+// verifyMAC checks the digest of the decrypted document against the MAC in
+// metadata, which is encrypted with the data key and bound to the document's
+// last modification time.
+func (d *sopsDecryptor) verifyMAC(metadata sopsMetadata) error {
+	if metadata.MAC == "" {
+		return fmt.Errorf("document has no MAC")
+	}
+	if !sopsValuePattern.MatchString(metadata.MAC) {
+		return fmt.Errorf("document MAC is not encrypted")
+	}
+	lastModified, err := time.Parse(time.RFC3339, metadata.LastModified)
+	if err != nil {
+		return fmt.Errorf("invalid lastmodified: %w", err)
+	}
+
+	mac, _, err := decryptSOPSValue(metadata.MAC, d.dataKey, lastModified.Format(time.RFC3339))
+	if err != nil {
+		return fmt.Errorf("failed to decrypt MAC: %w", err)
+	}
+	digest := []byte(fmt.Sprintf("%X", d.mac.Sum(nil)))
+	if subtle.ConstantTimeCompare(mac, digest) != 1 {
+		return fmt.Errorf("MAC mismatch: the document was modified after it was encrypted")
+	}
+	return nil
+}
+
+// sopsAdditionalData returns the additional data a value at path is
+// encrypted with.
+func sopsAdditionalData(path []string) string {
+	return strings.Join(path, ":") + ":"
+}
+
+// sopsPlaintext returns the bytes SOPS authenticates for a plaintext value.
+func sopsPlaintext(value interface{}) ([]byte, error) {
+	switch v := value.(type) {
+	case string:
+		return []byte(v), nil
+	case int:
+		return []byte(strconv.Itoa(v)), nil
+	case float64:
+		return []byte(strconv.FormatFloat(v, 'f', -1, 64)), nil
+	case bool:
+		if v {
+			return []byte("True"), nil
+		}
+		return []byte("False"), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %T", value)
+	}
+}
+
+// sopsNumber returns a number as written in the document. Numbers that are
+// not valid JSON (e.g. .inf) are returned as strings.
+func sopsNumber(value string) interface{} {
+	if json.Valid([]byte(value)) {
+		return json.Number(value)
+	}
+	return value
+}
+
+// decryptSOPSValue decrypts a single ENC[AES256_GCM,...] value and returns
+// its plaintext and SOPS type.
+func decryptSOPSValue(value string, dataKey []byte, additionalData string) ([]byte, string, error) {
+	match := sopsValuePattern.FindStringSubmatch(value)
+	data, err := base64.StdEncoding.DecodeString(match[1])
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid encrypted data: %w", err)
+	}
+	iv, err := base64.StdEncoding.DecodeString(match[2])
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid iv: %w", err)
+	}
+	tag, err := base64.StdEncoding.DecodeString(match[3])
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid tag: %w", err)
+	}
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid data key: %w", err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		return nil, "", err
+	}
+	plaintext, err := gcm.Open(nil, iv, bytes.Join([][]byte{data, tag}, nil), []byte(additionalData))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decrypt value at %s: %w", strings.TrimSuffix(additionalData, ":"), err)
+	}
+	return plaintext, match[4], nil
+}
@@ -0,0 +1,326 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha512"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// sopsEncryptValue encrypts plaintext the way SOPS does for a value at path.
+func sopsEncryptValue(t *testing.T, dataKey []byte, plaintext, valueType string, path ...string) string {
+	t.Helper()
+	return sopsEncrypt(t, dataKey, plaintext, valueType, sopsAdditionalData(path))
+}
+
+// sopsEncrypt encrypts plaintext with the given additional data.
+func sopsEncrypt(t *testing.T, dataKey []byte, plaintext, valueType, additionalData string) string {
+	t.Helper()
+
+	block, err := aes.NewCipher(dataKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	iv := make([]byte, 32)
+	if _, err := rand.Read(iv); err != nil {
+		t.Fatal(err)
+	}
+	gcm, err := cipher.NewGCMWithNonceSize(block, len(iv))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed := gcm.Seal(nil, iv, []byte(plaintext), []byte(additionalData))
+	data, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return fmt.Sprintf("ENC[AES256_GCM,data:%s,iv:%s,tag:%s,type:%s]",
+		base64.StdEncoding.EncodeToString(data),
+		base64.StdEncoding.EncodeToString(iv),
+		base64.StdEncoding.EncodeToString(tag),
+		valueType)
+}
+
+// sopsEncryptDataKey encrypts dataKey for recipient as an armored age file.
+func sopsEncryptDataKey(t *testing.T, dataKey []byte, recipient age.Recipient) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+	armorWriter := armor.NewWriter(&buf)
+	w, err := age.Encrypt(armorWriter, recipient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := w.Write(dataKey); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := armorWriter.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+// newSOPSFixture returns an age key file and a SOPS-encrypted YAML document.
+// It is built with the same path and MAC rules as sops.go, so it exercises
+// tampering but not compatibility; TestSOPSProvider_CLIFixture covers that.
+func newSOPSFixture(t *testing.T) (keyFile, document string) {
+	t.Helper()
+
+	identity, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFile = filepath.Join(t.TempDir(), "keys.txt")
+	keyContent := "# created: 2024-01-01T00:00:00Z\n# public key: " + identity.Recipient().String() + "\n" + identity.String() + "\n"
+	if err := os.WriteFile(keyFile, []byte(keyContent), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	dataKey := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, dataKey); err != nil {
+		t.Fatal(err)
+	}
+
+	// A second recipient the controller cannot decrypt comes first, as in
+	// documents encrypted for several teams.
+	other, err := age.GenerateX25519Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	indent := func(s string) string {
+		return "            " + strings.ReplaceAll(strings.TrimSpace(s), "\n", "\n            ")
+	}
+
+	// The MAC covers the plaintext of every value and comment in document
+	// order, including values left unencrypted.
+	mac := sha512.New()
+	for _, plaintext := range []string{"admin", "5432", "1234567", "0.125", "True", " database hosts", "db1", "db2", "eu-west-1"} {
+		mac.Write([]byte(plaintext))
+	}
+
+	document = fmt.Sprintf(`username: %s
+port: %s
+replicas: %s
+ratio: %s
+ssl: %s
+#%s
+database:
+    hosts:
+        - %s
+        - %s
+region_unencrypted: eu-west-1
+sops:
+    age:
+        - recipient: %s
+          enc: |
+%s
+        - recipient: %s
+          enc: |
+%s
+    lastmodified: "2024-01-01T00:00:00Z"
+    mac: %s
+    unencrypted_suffix: _unencrypted
+    version: 3.8.1
+`,
+		sopsEncryptValue(t, dataKey, "admin", "str", "username"),
+		sopsEncryptValue(t, dataKey, "5432", "int", "port"),
+		sopsEncryptValue(t, dataKey, "1234567", "int", "replicas"),
+		sopsEncryptValue(t, dataKey, "0.125", "float", "ratio"),
+		sopsEncryptValue(t, dataKey, "True", "bool", "ssl"),
+		sopsEncryptValue(t, dataKey, " database hosts", "comment"),
+		sopsEncryptValue(t, dataKey, "db1", "str", "database", "hosts"),
+		sopsEncryptValue(t, dataKey, "db2", "str", "database", "hosts"),
+		other.Recipient().String(), indent(sopsEncryptDataKey(t, dataKey, other.Recipient())),
+		identity.Recipient().String(), indent(sopsEncryptDataKey(t, dataKey, identity.Recipient())),
+		sopsEncrypt(t, dataKey, fmt.Sprintf("%X", mac.Sum(nil)), "str", "2024-01-01T00:00:00Z"),
+	)
+	return keyFile, document
+}
+
+func TestSOPSProvider_Name(t *testing.T) {
+	provider := &SOPSProvider{}
+	if got := provider.Name(); got != "sops" {
+		t.Errorf("Name() = %v, want %v", got, "sops")
+	}
+}
+
+func TestSOPSProvider_FetchSecret(t *testing.T) {
+	keyFile, document := newSOPSFixture(t)
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "prod/db.enc.yaml"), document)
+	tampered := strings.Replace(document, "username: ENC[AES256_GCM,data:", "username: ENC[AES256_GCM,data:AA", 1)
+	writeTestFile(t, filepath.Join(root, "prod/tampered.enc.yaml"), tampered)
+	writeTestFile(t, filepath.Join(root, "prod/plain.yaml"), "username: admin\n")
+	writeTestFile(t, filepath.Join(root, "prod/injected.enc.yaml"), strings.Replace(document, "sops:", "password: injected\nsops:", 1))
+	writeTestFile(t, filepath.Join(root, "prod/injected-unencrypted.enc.yaml"), strings.Replace(document, "sops:", "password_unencrypted: injected\nsops:", 1))
+	removed := regexp.MustCompile(`(?m)^ssl: .*\n`).ReplaceAllString(document, "")
+	writeTestFile(t, filepath.Join(root, "prod/removed.enc.yaml"), removed)
+	withoutMAC := regexp.MustCompile(`(?m)^    mac: .*\n`).ReplaceAllString(document, "")
+	writeTestFile(t, filepath.Join(root, "prod/without-mac.enc.yaml"), withoutMAC)
+
+	reader := fake.NewClientBuilder().WithObjects(
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        "encrypted-config",
+				Namespace:   "platform-secrets",
+				Annotations: map[string]string{KubernetesAllowedNamespacesAnnotation: "tenant-a"},
+			},
+			Data: map[string]string{"secrets.yaml": document},
+		},
+	).Build()
+
+	provider, err := NewSOPSProvider(SOPSConfig{AgeKeyFile: keyFile, Root: root, Client: reader})
+	if err != nil {
+		t.Fatalf("NewSOPSProvider() error = %v", err)
+	}
+
+	want := map[string]string{
+		"username":           "admin",
+		"port":               "5432",
+		"replicas":           "1234567",
+		"ratio":              "0.125",
+		"ssl":                "true",
+		"database":           `{"hosts":["db1","db2"]}`,
+		"region_unencrypted": "eu-west-1",
+	}
+
+	tests := []struct {
+		name      string
+		namespace string
+		path      string
+		want      map[string]string
+		wantErr   bool
+	}{
+		{name: "Encrypted file", path: "prod/db.enc.yaml", want: want},
+		{name: "Encrypted ConfigMap", namespace: "tenant-a", path: "configmap:platform-secrets/encrypted-config/secrets.yaml", want: want},
+		{name: "ConfigMap from disallowed namespace", namespace: "tenant-b", path: "configmap:platform-secrets/encrypted-config/secrets.yaml", wantErr: true},
+		{name: "ConfigMap missing key", namespace: "tenant-a", path: "configmap:platform-secrets/encrypted-config/other.yaml", wantErr: true},
+		{name: "Tampered value", path: "prod/tampered.enc.yaml", wantErr: true},
+		{name: "Injected plaintext value", path: "prod/injected.enc.yaml", wantErr: true},
+		{name: "Injected unencrypted value", path: "prod/injected-unencrypted.enc.yaml", wantErr: true},
+		{name: "Removed value", path: "prod/removed.enc.yaml", wantErr: true},
+		{name: "Missing MAC", path: "prod/without-mac.enc.yaml", wantErr: true},
+		{name: "Document without sops metadata", path: "prod/plain.yaml", wantErr: true},
+		{name: "Path escaping root", path: "../db.enc.yaml", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.namespace != "" {
+				ctx = WithRequestNamespace(ctx, tt.namespace)
+			}
+
+			got, err := provider.FetchSecret(ctx, tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d keys, want %d keys: %v", len(got), len(tt.want), got)
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("key %q: got %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+// TestSOPSProvider_CLIFixture decrypts testdata/sops/secrets.enc.yaml, which
+// testdata/sops/generate.sh produces with the sops CLI.
+func TestSOPSProvider_CLIFixture(t *testing.T) {
+	document, err := os.ReadFile("testdata/sops/secrets.enc.yaml")
+	if os.IsNotExist(err) {
+		t.Skip("testdata/sops/secrets.enc.yaml is missing; run testdata/sops/generate.sh")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root := t.TempDir()
+	writeTestFile(t, filepath.Join(root, "secrets.enc.yaml"), string(document))
+	removed := regexp.MustCompile(`(?m)^debug: .*\n`).ReplaceAllString(string(document), "")
+	writeTestFile(t, filepath.Join(root, "removed.enc.yaml"), removed)
+	swapped := regexp.MustCompile(`(?m)^public_url_unencrypted: .*$`).ReplaceAllString(string(document), "public_url_unencrypted: https://attacker.example")
+	writeTestFile(t, filepath.Join(root, "swapped.enc.yaml"), swapped)
+
+	provider, err := NewSOPSProvider(SOPSConfig{AgeKeyFile: "testdata/sops/age.key", Root: root})
+	if err != nil {
+		t.Fatalf("NewSOPSProvider() error = %v", err)
+	}
+
+	got, err := provider.FetchSecret(context.Background(), "secrets.enc.yaml")
+	if err != nil {
+		t.Fatalf("FetchSecret() error = %v", err)
+	}
+	want := map[string]string{
+		"api_token":              "s3cret",
+		"database":               `{"hosts":["db-1.internal","db-2.internal"],"port":5432,"ssl":true}`,
+		"replicas":               "1234567",
+		"ratio":                  "0.125",
+		"debug":                  "false",
+		"public_url_unencrypted": "https://app.example.com",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d keys, want %d keys: %v", len(got), len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("key %q: got %q, want %q", k, got[k], v)
+		}
+	}
+
+	for _, path := range []string{"removed.enc.yaml", "swapped.enc.yaml"} {
+		if _, err := provider.FetchSecret(context.Background(), path); err == nil || !strings.Contains(err.Error(), "MAC mismatch") {
+			t.Errorf("FetchSecret(%s) error = %v, want MAC mismatch", path, err)
+		}
+	}
+}
+
+func TestSOPSProvider_WrongKey(t *testing.T) {
+	_, document := newSOPSFixture(t)
+	otherKeyFile, _ := newSOPSFixture(t)
+
+	provider, err := NewSOPSProvider(SOPSConfig{AgeKeyFile: otherKeyFile})
+	if err != nil {
+		t.Fatalf("NewSOPSProvider() error = %v", err)
+	}
+	if _, err := provider.decrypt([]byte(document)); err == nil {
+		t.Error("decrypt() expected error for a key that is not a recipient")
+	}
+}
+
+func TestNewSOPSProvider_InvalidKeyFile(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "keys.txt")
+	writeTestFile(t, keyFile, "not an age key\n")
+
+	if _, err := NewSOPSProvider(SOPSConfig{AgeKeyFile: keyFile}); err == nil {
+		t.Error("NewSOPSProvider() expected error for invalid key file")
+	}
+	if _, err := NewSOPSProvider(SOPSConfig{AgeKeyFile: filepath.Join(t.TempDir(), "missing")}); err == nil {
+		t.Error("NewSOPSProvider() expected error for missing key file")
+	}
+}
@@ -0,0 +1,10 @@
+#!/bin/sh
+# Encrypts secrets.yaml into secrets.enc.yaml with the sops CLI and the test
+# key in age.key. The SOPS provider tests decrypt the result, so that they
+# check compatibility with sops itself rather than with the test helpers.
+set -eu
+
+cd "$(dirname "$0")"
+recipient=$(sed -n 's/^# public key: //p' age.key)
+SOPS_AGE_KEY_FILE=age.key sops --encrypt --age "$recipient" \
+	--input-type yaml --output-type yaml secrets.yaml >secrets.enc.yaml
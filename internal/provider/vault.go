@@ -0,0 +1,289 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// VaultConfig holds the connection settings for a HashiCorp Vault server.
+type VaultConfig struct {
+	// Address is the Vault server URL (e.g., "https://vault.example.com:8200").
+	Address string `yaml:"address"`
+	// Token is a static Vault token used to authenticate requests.
+	// Ignored when KubernetesRole is set.
+	Token string `yaml:"token"`
+	// KubernetesRole enables the Kubernetes auth method and names the Vault
+	// role to log in as.
+	KubernetesRole string `yaml:"kubernetesRole"`
+	// KubernetesMountPath is the mount path of the Kubernetes auth backend.
+	// Defaults to "kubernetes".
+	KubernetesMountPath string `yaml:"kubernetesMountPath"`
+	// ServiceAccountTokenPath is the path of the ServiceAccount JWT presented
+	// to Vault. Defaults to the in-cluster ServiceAccount token.
+	ServiceAccountTokenPath string `yaml:"serviceAccountTokenPath"`
+	// Namespace is the Vault Enterprise namespace (optional).
+	Namespace string `yaml:"namespace"`
+	// HTTPClient sends the KV and auth requests, e.g. with a transport
+	// trusting a private CA (optional).
+	HTTPClient *http.Client `yaml:"-"`
+}
+
+// VaultConfigFromEnv builds a VaultConfig from the standard Vault environment
+// variables VAULT_ADDR, VAULT_TOKEN and VAULT_NAMESPACE.
+func VaultConfigFromEnv() VaultConfig {
+	return VaultConfig{
+		Address:   os.Getenv("VAULT_ADDR"),
+		Token:     os.Getenv("VAULT_TOKEN"),
+		Namespace: os.Getenv("VAULT_NAMESPACE"),
+	}
+}
+
+// vaultReservedPrefixes are Vault API paths that never hold KV secrets. They
+// are rejected before any request so that an annotation cannot make the
+// controller read its own token or auth configuration.
+var vaultReservedPrefixes = []string{"sys/", "auth/", "identity/", "cubbyhole/"}
+
+// vaultKVMountTypes are the secrets engine types served by the provider.
+var vaultKVMountTypes = []string{"kv", "generic"}
+
+// vaultMount describes a secrets engine mount and its KV version.
+type vaultMount struct {
+	path    string
+	version int
+}
+
+// VaultKVProvider implements SecretProvider for HashiCorp Vault KV secrets engines.
+// Both KV v1 and KV v2 mounts are supported; the mount version is detected
+// automatically and cached per mount.
+type VaultKVProvider struct {
+	address    string
+	namespace  string
+	httpClient *http.Client
+	auth       *vaultKubernetesAuth
+
+	tokenMu sync.Mutex
+	token   vaultToken
+	now     func() time.Time
+
+	mu     sync.RWMutex
+	mounts []vaultMount
+}
+
+// NewVaultKVProvider creates a new Vault KV provider.
+func NewVaultKVProvider(cfg VaultConfig) (*VaultKVProvider, error) {
+	if cfg.Address == "" {
+		return nil, fmt.Errorf("vault address is required")
+	}
+	if _, err := url.Parse(cfg.Address); err != nil {
+		return nil, fmt.Errorf("invalid vault address %q: %w", cfg.Address, err)
+	}
+
+	p := &VaultKVProvider{
+		address:    strings.TrimRight(cfg.Address, "/"),
+		namespace:  cfg.Namespace,
+		httpClient: httpClientOrDefault(cfg.HTTPClient),
+		token:      vaultToken{clientToken: cfg.Token},
+		now:        time.Now,
+	}
+
+	if cfg.KubernetesRole != "" {
+		p.auth = &vaultKubernetesAuth{
+			role:      cfg.KubernetesRole,
+			mountPath: strings.Trim(cfg.KubernetesMountPath, "/"),
+			tokenPath: cfg.ServiceAccountTokenPath,
+		}
+		if p.auth.mountPath == "" {
+			p.auth.mountPath = defaultVaultKubernetesMountPath
+		}
+		if p.auth.tokenPath == "" {
+			p.auth.tokenPath = defaultServiceAccountTokenPath
+		}
+		p.token = vaultToken{}
+	}
+
+	return p, nil
+}
+
+// Name returns the provider identifier.
+func (p *VaultKVProvider) Name() string {
+	return "vault-kv"
+}
+
+// FetchSecret retrieves a secret from a Vault KV mount.
+// The path includes the mount, e.g. "secret/myapp/database".
+func (p *VaultKVProvider) FetchSecret(ctx context.Context, path string) (map[string]string, error) {
+	path = strings.Trim(path, "/")
+	if err := validateVaultSecretPath(path); err != nil {
+		return nil, err
+	}
+
+	mount, err := p.resolveMount(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rawData map[string]interface{}
+	if mount.version == 2 {
+		rawData, err = p.readKVv2(ctx, mount, path)
+	} else {
+		rawData, err = p.readKVv1(ctx, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return stringifySecretData(rawData), nil
+}
+
+// validateVaultSecretPath rejects empty paths, paths that are not plain
+// slash-separated names and paths under vaultReservedPrefixes.
+func validateVaultSecretPath(path string) error {
+	if path == "" {
+		return fmt.Errorf("vault secret path is empty")
+	}
+	if strings.ContainsAny(path, "?#") {
+		return fmt.Errorf("invalid vault secret path %q", path)
+	}
+	for _, segment := range strings.Split(path, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("invalid vault secret path %q", path)
+		}
+	}
+	for _, prefix := range vaultReservedPrefixes {
+		if strings.HasPrefix(path+"/", prefix) {
+			return fmt.Errorf("vault path %s is not a KV secret", path)
+		}
+	}
+	return nil
+}
+
+// readKVv1 reads a secret from a KV v1 mount.
+func (p *VaultKVProvider) readKVv1(ctx context.Context, path string) (map[string]interface{}, error) {
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := p.get(ctx, path, &response); err != nil {
+		return nil, err
+	}
+	return response.Data, nil
+}
+
+// readKVv2 reads the latest version of a secret from a KV v2 mount.
+func (p *VaultKVProvider) readKVv2(ctx context.Context, mount vaultMount, path string) (map[string]interface{}, error) {
+	secretPath := strings.TrimPrefix(path, mount.path)
+	if secretPath == "" {
+		return nil, fmt.Errorf("vault secret path %s refers to the mount itself", path)
+	}
+
+	var response struct {
+		Data struct {
+			Data map[string]interface{} `json:"data"`
+		} `json:"data"`
+	}
+	if err := p.get(ctx, mount.path+"data/"+secretPath, &response); err != nil {
+		return nil, err
+	}
+	if response.Data.Data == nil {
+		return nil, fmt.Errorf("vault secret %s has no data (latest version may be deleted)", path)
+	}
+	return response.Data.Data, nil
+}
+
+// resolveMount returns the mount serving path, querying Vault on a cache miss.
+func (p *VaultKVProvider) resolveMount(ctx context.Context, path string) (vaultMount, error) {
+	p.mu.RLock()
+	for _, m := range p.mounts {
+		if strings.HasPrefix(path, m.path) {
+			p.mu.RUnlock()
+			return m, nil
+		}
+	}
+	p.mu.RUnlock()
+
+	var response struct {
+		Data struct {
+			Path    string            `json:"path"`
+			Type    string            `json:"type"`
+			Options map[string]string `json:"options"`
+		} `json:"data"`
+	}
+	// Without the mount type the path could be any Vault API endpoint, so
+	// there is no fallback when it cannot be detected.
+	if err := p.get(ctx, "sys/internal/ui/mounts/"+path, &response); err != nil {
+		return vaultMount{}, fmt.Errorf("failed to detect vault mount for %s: %w", path, err)
+	}
+	if !slices.Contains(vaultKVMountTypes, response.Data.Type) {
+		return vaultMount{}, fmt.Errorf("vault path %s is served by a %q mount, not a KV secrets engine", path, response.Data.Type)
+	}
+	if response.Data.Path == "" || !strings.HasPrefix(path, response.Data.Path) {
+		return vaultMount{}, fmt.Errorf("failed to detect vault mount for %s: unexpected mount path %q", path, response.Data.Path)
+	}
+
+	mount := vaultMount{path: response.Data.Path, version: 1}
+	if response.Data.Options["version"] == "2" {
+		mount.version = 2
+	}
+
+	p.mu.Lock()
+	p.mounts = append(p.mounts, mount)
+	p.mu.Unlock()
+
+	return mount, nil
+}
+
+// get performs an authenticated GET against the Vault HTTP API and decodes
+// the JSON response into out. When Kubernetes auth is enabled, a permission
+// denied response triggers a single re-login and retry, since the cached
+// token may have been revoked.
+func (p *VaultKVProvider) get(ctx context.Context, path string, out interface{}) error {
+	token, err := p.clientToken(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = p.do(ctx, http.MethodGet, path, token, nil, out)
+	if p.auth != nil && isHTTPStatus(err, http.StatusForbidden) {
+		p.invalidateToken(token)
+		if token, err = p.clientToken(ctx); err != nil {
+			return err
+		}
+		err = p.do(ctx, http.MethodGet, path, token, nil, out)
+	}
+	return err
+}
+
+// do sends a request to the Vault HTTP API and decodes the JSON response into out.
+func (p *VaultKVProvider) do(ctx context.Context, method, path, token string, body interface{}, out interface{}) error {
+	req, err := newJSONRequest(ctx, method, p.address+"/v1/"+path, body)
+	if err != nil {
+		return fmt.Errorf("vault request failed: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("X-Vault-Token", token)
+	}
+	if p.namespace != "" {
+		req.Header.Set("X-Vault-Namespace", p.namespace)
+	}
+
+	if err := doJSON(p.httpClient, req, out, vaultErrorMessage); err != nil {
+		return fmt.Errorf("vault request failed: %w", err)
+	}
+	return nil
+}
+
+// vaultErrorMessage joins the errors of a Vault error response.
+func vaultErrorMessage(body []byte) string {
+	var errResponse struct {
+		Errors []string `json:"errors"`
+	}
+	_ = json.Unmarshal(body, &errResponse)
+	return strings.Join(errResponse.Errors, "; ")
+}
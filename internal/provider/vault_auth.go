@@ -0,0 +1,137 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultVaultKubernetesMountPath is the default mount path of the Vault Kubernetes auth backend.
+	defaultVaultKubernetesMountPath = "kubernetes"
+	// defaultServiceAccountTokenPath is where Kubernetes mounts the pod's ServiceAccount token.
+	defaultServiceAccountTokenPath = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	// vaultTokenRenewFraction is the fraction of the token TTL after which the
+	// token is renewed, leaving headroom before it expires.
+	vaultTokenRenewFraction = 2.0 / 3.0
+)
+
+// vaultKubernetesAuth holds the settings for Vault's Kubernetes auth method.
+type vaultKubernetesAuth struct {
+	role      string
+	mountPath string
+	tokenPath string
+}
+
+// vaultToken is a cached Vault client token and its lease timing.
+type vaultToken struct {
+	clientToken string
+	renewable   bool
+	// renewAt is when the token should be renewed; zero means it never expires.
+	renewAt time.Time
+	// expiresAt is when the token lease runs out; zero means it never expires.
+	expiresAt time.Time
+}
+
+// vaultAuthResponse is the "auth" block returned by Vault login and renew endpoints.
+type vaultAuthResponse struct {
+	Auth *struct {
+		ClientToken   string `json:"client_token"`
+		LeaseDuration int    `json:"lease_duration"`
+		Renewable     bool   `json:"renewable"`
+	} `json:"auth"`
+}
+
+// clientToken returns a valid Vault token, logging in or renewing the cached
+// token as needed when Kubernetes auth is enabled.
+func (p *VaultKVProvider) clientToken(ctx context.Context) (string, error) {
+	p.tokenMu.Lock()
+	defer p.tokenMu.Unlock()
+
+	if p.auth == nil {
+		return p.token.clientToken, nil
+	}
+
+	now := p.now()
+	if p.token.clientToken != "" {
+		if p.token.renewAt.IsZero() || now.Before(p.token.renewAt) {
+			return p.token.clientToken, nil
+		}
+		if p.token.renewable && now.Before(p.token.expiresAt) {
+			token, err := p.renewToken(ctx, p.token.clientToken)
+			if err == nil {
+				p.token = token
+				return p.token.clientToken, nil
+			}
+			// Fall through to a fresh login if renewal fails.
+		}
+	}
+
+	token, err := p.login(ctx)
+	if err != nil {
+		return "", err
+	}
+	p.token = token
+	return p.token.clientToken, nil
+}
+
+// invalidateToken discards the cached token if it is still the given token,
+// forcing the next request to log in again.
+func (p *VaultKVProvider) invalidateToken(token string) {
+	p.tokenMu.Lock()
+	defer p.tokenMu.Unlock()
+
+	if p.token.clientToken == token {
+		p.token = vaultToken{}
+	}
+}
+
+// login authenticates to Vault with the ServiceAccount JWT.
+func (p *VaultKVProvider) login(ctx context.Context) (vaultToken, error) {
+	jwt, err := os.ReadFile(p.auth.tokenPath)
+	if err != nil {
+		return vaultToken{}, fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	body := map[string]string{
+		"role": p.auth.role,
+		"jwt":  strings.TrimSpace(string(jwt)),
+	}
+
+	var response vaultAuthResponse
+	if err := p.do(ctx, http.MethodPost, "auth/"+p.auth.mountPath+"/login", "", body, &response); err != nil {
+		return vaultToken{}, fmt.Errorf("vault kubernetes login failed for role %s: %w", p.auth.role, err)
+	}
+	return p.tokenFromAuth(response)
+}
+
+// renewToken extends the lease of the given token.
+func (p *VaultKVProvider) renewToken(ctx context.Context, token string) (vaultToken, error) {
+	var response vaultAuthResponse
+	if err := p.do(ctx, http.MethodPost, "auth/token/renew-self", token, map[string]string{}, &response); err != nil {
+		return vaultToken{}, fmt.Errorf("vault token renewal failed: %w", err)
+	}
+	return p.tokenFromAuth(response)
+}
+
+// tokenFromAuth converts a login or renew response into a cached token.
+func (p *VaultKVProvider) tokenFromAuth(response vaultAuthResponse) (vaultToken, error) {
+	if response.Auth == nil || response.Auth.ClientToken == "" {
+		return vaultToken{}, fmt.Errorf("vault response did not contain a client token")
+	}
+
+	token := vaultToken{
+		clientToken: response.Auth.ClientToken,
+		renewable:   response.Auth.Renewable,
+	}
+	if response.Auth.LeaseDuration > 0 {
+		now := p.now()
+		ttl := time.Duration(response.Auth.LeaseDuration) * time.Second
+		token.expiresAt = now.Add(ttl)
+		token.renewAt = now.Add(time.Duration(float64(ttl) * vaultTokenRenewFraction))
+	}
+	return token, nil
+}
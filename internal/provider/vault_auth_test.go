@@ -0,0 +1,186 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeVaultAuth mimics the Vault Kubernetes auth and token renewal endpoints
+// in front of a KV v1 secret at "kv/app".
+type fakeVaultAuth struct {
+	mu       sync.Mutex
+	logins   int
+	renewals int
+	valid    map[string]bool
+}
+
+func (f *fakeVaultAuth) handler(t *testing.T) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/auth/k8s-cluster/login", func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid login body: %v", err)
+		}
+		if body["role"] != "jasm" || body["jwt"] != "sa-jwt" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"errors":["invalid role or jwt"]}`))
+			return
+		}
+		f.mu.Lock()
+		f.logins++
+		token := fmt.Sprintf("token-%d", f.logins)
+		f.valid[token] = true
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": token, "lease_duration": 60, "renewable": true},
+		})
+	})
+	mux.HandleFunc("/v1/auth/token/renew-self", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		token := r.Header.Get("X-Vault-Token")
+		if !f.valid[token] {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		f.renewals++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"auth": map[string]interface{}{"client_token": token, "lease_duration": 60, "renewable": true},
+		})
+	})
+	mux.HandleFunc("/v1/sys/internal/ui/mounts/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"path":"kv/","type":"kv","options":{"version":"1"}}}`))
+	})
+	mux.HandleFunc("/v1/kv/app", func(w http.ResponseWriter, r *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		if !f.valid[r.Header.Get("X-Vault-Token")] {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"password":"s3cret"}}`))
+	})
+	return mux
+}
+
+func newKubernetesAuthProvider(t *testing.T, fake *fakeVaultAuth) *VaultKVProvider {
+	t.Helper()
+
+	server := httptest.NewServer(fake.handler(t))
+	t.Cleanup(server.Close)
+
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(tokenPath, []byte("sa-jwt\n"), 0o600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+
+	provider, err := NewVaultKVProvider(VaultConfig{
+		Address:                 server.URL,
+		KubernetesRole:          "jasm",
+		KubernetesMountPath:     "/k8s-cluster/",
+		ServiceAccountTokenPath: tokenPath,
+	})
+	if err != nil {
+		t.Fatalf("NewVaultKVProvider() error = %v", err)
+	}
+	return provider
+}
+
+func TestVaultKubernetesAuth_LoginAndCache(t *testing.T) {
+	fake := &fakeVaultAuth{valid: map[string]bool{}}
+	provider := newKubernetesAuthProvider(t, fake)
+
+	for i := 0; i < 3; i++ {
+		got, err := provider.FetchSecret(context.Background(), "kv/app")
+		if err != nil {
+			t.Fatalf("FetchSecret() error = %v", err)
+		}
+		if got["password"] != "s3cret" {
+			t.Errorf("password = %q, want %q", got["password"], "s3cret")
+		}
+	}
+
+	if fake.logins != 1 {
+		t.Errorf("logins = %d, want 1", fake.logins)
+	}
+}
+
+func TestVaultKubernetesAuth_RenewsBeforeExpiry(t *testing.T) {
+	fake := &fakeVaultAuth{valid: map[string]bool{}}
+	provider := newKubernetesAuthProvider(t, fake)
+
+	now := time.Now()
+	provider.now = func() time.Time { return now }
+
+	if _, err := provider.FetchSecret(context.Background(), "kv/app"); err != nil {
+		t.Fatalf("FetchSecret() error = %v", err)
+	}
+
+	// Past the renewal point but before the 60s lease expires.
+	now = now.Add(45 * time.Second)
+	if _, err := provider.FetchSecret(context.Background(), "kv/app"); err != nil {
+		t.Fatalf("FetchSecret() error = %v", err)
+	}
+
+	if fake.renewals != 1 {
+		t.Errorf("renewals = %d, want 1", fake.renewals)
+	}
+	if fake.logins != 1 {
+		t.Errorf("logins = %d, want 1", fake.logins)
+	}
+
+	// Past expiry the token must be replaced by a fresh login.
+	now = now.Add(5 * time.Minute)
+	if _, err := provider.FetchSecret(context.Background(), "kv/app"); err != nil {
+		t.Fatalf("FetchSecret() error = %v", err)
+	}
+	if fake.logins != 2 {
+		t.Errorf("logins = %d, want 2", fake.logins)
+	}
+}
+
+func TestVaultKubernetesAuth_ReloginOnForbidden(t *testing.T) {
+	fake := &fakeVaultAuth{valid: map[string]bool{}}
+	provider := newKubernetesAuthProvider(t, fake)
+
+	if _, err := provider.FetchSecret(context.Background(), "kv/app"); err != nil {
+		t.Fatalf("FetchSecret() error = %v", err)
+	}
+
+	// Revoke every issued token.
+	fake.mu.Lock()
+	fake.valid = map[string]bool{}
+	fake.mu.Unlock()
+
+	if _, err := provider.FetchSecret(context.Background(), "kv/app"); err != nil {
+		t.Fatalf("FetchSecret() error = %v", err)
+	}
+	if fake.logins != 2 {
+		t.Errorf("logins = %d, want 2", fake.logins)
+	}
+}
+
+func TestVaultKubernetesAuth_MissingTokenFile(t *testing.T) {
+	provider, err := NewVaultKVProvider(VaultConfig{
+		Address:                 "http://127.0.0.1:0",
+		KubernetesRole:          "jasm",
+		ServiceAccountTokenPath: filepath.Join(t.TempDir(), "missing"),
+	})
+	if err != nil {
+		t.Fatalf("NewVaultKVProvider() error = %v", err)
+	}
+
+	if _, err := provider.FetchSecret(context.Background(), "kv/app"); err == nil {
+		t.Error("FetchSecret() expected error for missing service account token")
+	}
+}
@@ -0,0 +1,188 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// newFakeVault starts an httptest server that mimics a Vault dev server with
+// a KV v2 mount at "secret/", a KV v1 mount at "kv/" and a transit mount at
+// "transit/".
+func newFakeVault(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/v1/sys/internal/ui/mounts/", func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/v1/sys/internal/ui/mounts/secret/"):
+			w.Write([]byte(`{"data":{"path":"secret/","type":"kv","options":{"version":"2"}}}`))
+		case strings.HasPrefix(r.URL.Path, "/v1/sys/internal/ui/mounts/kv/"):
+			w.Write([]byte(`{"data":{"path":"kv/","type":"kv","options":{"version":"1"}}}`))
+		case strings.HasPrefix(r.URL.Path, "/v1/sys/internal/ui/mounts/transit/"):
+			w.Write([]byte(`{"data":{"path":"transit/","type":"transit","options":null}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"errors":[]}`))
+		}
+	})
+	mux.HandleFunc("/v1/secret/data/myapp/database", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "root" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"errors":["permission denied"]}`))
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"username":"admin","password":"s3cret","port":5432},"metadata":{"version":3}}}`))
+	})
+	mux.HandleFunc("/v1/kv/legacy/app", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"api_key":"abc123","enabled":true}}`))
+	})
+	mux.HandleFunc("/v1/transit/export/encryption-key/app", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"keys":{"1":"a2V5"}}}`))
+	})
+	mux.HandleFunc("/v1/auth/token/lookup-self", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"id":"` + r.Header.Get("X-Vault-Token") + `"}}`))
+	})
+	mux.HandleFunc("/v1/unmounted/app", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"data":{"api_key":"abc123"}}`))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestVaultKVProvider_Name(t *testing.T) {
+	provider := &VaultKVProvider{}
+	if got := provider.Name(); got != "vault-kv" {
+		t.Errorf("Name() = %v, want %v", got, "vault-kv")
+	}
+}
+
+func TestVaultKVProvider_FetchSecret(t *testing.T) {
+	server := newFakeVault(t)
+
+	tests := []struct {
+		name    string
+		token   string
+		path    string
+		want    map[string]string
+		wantErr bool
+	}{
+		{
+			name:  "KV v2 mount",
+			token: "root",
+			path:  "secret/myapp/database",
+			want:  map[string]string{"username": "admin", "password": "s3cret", "port": "5432"},
+		},
+		{
+			name:  "KV v1 mount",
+			token: "root",
+			path:  "/kv/legacy/app",
+			want:  map[string]string{"api_key": "abc123", "enabled": "true"},
+		},
+		{
+			name:    "Permission denied",
+			token:   "invalid",
+			path:    "secret/myapp/database",
+			wantErr: true,
+		},
+		{
+			name:    "Missing secret",
+			token:   "root",
+			path:    "secret/myapp/missing",
+			wantErr: true,
+		},
+		{
+			name:    "Empty path",
+			token:   "root",
+			path:    "",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewVaultKVProvider(VaultConfig{Address: server.URL, Token: tt.token})
+			if err != nil {
+				t.Fatalf("NewVaultKVProvider() error = %v", err)
+			}
+
+			got, err := provider.FetchSecret(context.Background(), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FetchSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if len(got) != len(tt.want) {
+				t.Errorf("got %d keys, want %d keys", len(got), len(tt.want))
+			}
+			for k, v := range tt.want {
+				if got[k] != v {
+					t.Errorf("key %q: got %q, want %q", k, got[k], v)
+				}
+			}
+		})
+	}
+}
+
+func TestVaultKVProvider_RejectsNonKVPaths(t *testing.T) {
+	server := newFakeVault(t)
+
+	provider, err := NewVaultKVProvider(VaultConfig{Address: server.URL, Token: "root"})
+	if err != nil {
+		t.Fatalf("NewVaultKVProvider() error = %v", err)
+	}
+
+	tests := []struct {
+		path    string
+		wantErr string
+	}{
+		{path: "transit/export/encryption-key/app", wantErr: `"transit" mount`},
+		{path: "unmounted/app", wantErr: "failed to detect vault mount"},
+		{path: "auth/token/lookup-self", wantErr: "not a KV secret"},
+		{path: "/sys/internal/ui/mounts/secret", wantErr: "not a KV secret"},
+		{path: "identity/entity/name/app", wantErr: "not a KV secret"},
+		{path: "cubbyhole/app", wantErr: "not a KV secret"},
+		{path: "secret/../auth/token/lookup-self", wantErr: "invalid vault secret path"},
+		{path: "secret//myapp", wantErr: "invalid vault secret path"},
+		{path: "secret/myapp?list=true", wantErr: "invalid vault secret path"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, err := provider.FetchSecret(context.Background(), tt.path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("FetchSecret() = %v, error = %v, want %q", got, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestVaultKVProvider_CachesMountVersion(t *testing.T) {
+	server := newFakeVault(t)
+
+	provider, err := NewVaultKVProvider(VaultConfig{Address: server.URL, Token: "root"})
+	if err != nil {
+		t.Fatalf("NewVaultKVProvider() error = %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := provider.FetchSecret(context.Background(), "secret/myapp/database"); err != nil {
+			t.Fatalf("FetchSecret() error = %v", err)
+		}
+	}
+
+	if len(provider.mounts) != 1 || provider.mounts[0].path != "secret/" || provider.mounts[0].version != 2 {
+		t.Errorf("unexpected cached mounts: %+v", provider.mounts)
+	}
+}
+
+func TestNewVaultKVProvider_RequiresAddress(t *testing.T) {
+	if _, err := NewVaultKVProvider(VaultConfig{}); err == nil {
+		t.Error("NewVaultKVProvider() expected error for empty address")
+	}
+}